@@ -0,0 +1,288 @@
+package texttools
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Code errors.
+var (
+	ErrInvalidCode         = errors.New("texttools: invalid code")
+	ErrCodeChecksum        = errors.New("texttools: code checksum mismatch")
+	ErrUnsupportedAlphabet = errors.New("texttools: alphabet size not supported by check algorithm")
+	ErrSeparatorInAlphabet = errors.New("texttools: code separator contains a char of the alphabet")
+)
+
+// CheckAlgorithm is the algorithm used to calculate the check char of a code.
+type CheckAlgorithm int
+
+// The check algorithms.
+const (
+	// CheckNone adds no check char.
+	CheckNone CheckAlgorithm = iota
+
+	// CheckLuhnModN is the Luhn algorithm for alphabets of an even number of chars, like AlphabetCrockford.
+	// It detects all single char errors and most swaps of adjacent chars.
+	// With an odd number of chars, doubling a value can give the same result as doubling another one,
+	// so alphabets like AlphabetUnambiguous, with 57 chars, are not supported.
+	CheckLuhnModN
+
+	// CheckDamm is the Damm algorithm, which detects all single char errors and all swaps of adjacent chars.
+	// It supports alphabets of 10 chars, like AlphabetNumeric, and powers of 2 from 4 to 64 chars, like AlphabetCrockford.
+	CheckDamm
+)
+
+// The Damm quasigroup of order 10, from Damm's thesis.
+var dammTable10 = [10][10]int{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+// Irreducible polynomials of GF(2^k), by alphabet size, used to build Damm quasigroups of those sizes.
+var dammPolynomials = map[int]int{4: 0x7, 8: 0xb, 16: 0x13, 32: 0x25, 64: 0x43}
+
+// Chars that are mistaken for each other, in the order they are tried when the char isn't in the alphabet.
+var codeLookAlikes = map[rune][]rune{
+	'O': {'0'}, 'Q': {'0'}, '0': {'O'},
+	'I': {'1'}, 'L': {'1'}, '1': {'I', 'L'},
+	'S': {'5'}, '5': {'S'},
+	'B': {'8'}, '8': {'B'},
+	'Z': {'2'}, '2': {'Z'},
+}
+
+// CodeOptions is the length, grouping, alphabet and check algorithm of codes.
+type CodeOptions struct {
+	// Length is the number of chars in the code, including the check char.
+	Length int
+
+	// GroupSize is the number of chars in each group, or 0 for no groups.
+	// Groups are separated by Separator.
+	GroupSize int
+	Separator string
+
+	// Alphabet is the chars used in the code. Empty uses AlphabetCrockford.
+	Alphabet string
+
+	Check CheckAlgorithm
+}
+
+// DefaultCodeOptions returns options for codes like "ABCD-EFGH-JKM7":
+// 3 groups of 4 chars from Crockford's base32, where the last char is a Luhn mod N check char.
+func DefaultCodeOptions() CodeOptions {
+	return CodeOptions{
+		Length:    12,
+		GroupSize: 4,
+		Separator: "-",
+		Alphabet:  AlphabetCrockford,
+		Check:     CheckLuhnModN,
+	}
+}
+
+// GenerateCode creates a random code, like a voucher or verification code, using the crypto rand package.
+func GenerateCode(opts CodeOptions) (string, error) {
+	alphabet, err := codeAlphabet(opts)
+	if err != nil {
+		return "", err
+	}
+	chars := []rune(alphabet)
+	length := opts.Length
+	if opts.Check != CheckNone {
+		length--
+	}
+	if length < 1 {
+		return "", ErrInvalidCode
+	}
+
+	code, err := defaultRandomGenerator.String(length, alphabet)
+	if err != nil {
+		return "", err
+	}
+
+	codeRunes := []rune(code)
+	if opts.Check != CheckNone {
+		check, err := codeCheckChar(codeRunes, chars, opts.Check)
+		if err != nil {
+			return "", err
+		}
+		codeRunes = append(codeRunes, check)
+	}
+
+	return formatCode(codeRunes, opts), nil
+}
+
+// ParseCode normalizes a code entered by a user and validates its length, chars and check char.
+// Spaces, dashes and separators that aren't in the alphabet are removed, the case is changed to the case of the alphabet,
+// and chars that aren't in the alphabet are replaced with their look-alikes, like "O" with "0" and "I" or "L" with "1".
+// The code is returned as GenerateCode formats it.
+func ParseCode(input string, opts CodeOptions) (string, error) {
+	alphabet, err := codeAlphabet(opts)
+	if err != nil {
+		return "", err
+	}
+	chars := []rune(alphabet)
+
+	var code []rune
+	for _, r := range input {
+		if (unicode.IsSpace(r) || r == '-' || strings.ContainsRune(opts.Separator, r)) && !strings.ContainsRune(alphabet, r) {
+			continue
+		}
+		r = normalizeCodeRune(r, alphabet)
+		if !strings.ContainsRune(alphabet, r) {
+			return "", ErrInvalidCode
+		}
+		code = append(code, r)
+	}
+	if len(code) != opts.Length || len(code) == 0 {
+		return "", ErrInvalidCode
+	}
+
+	if opts.Check != CheckNone {
+		check, err := codeCheckChar(code[:len(code)-1], chars, opts.Check)
+		if err != nil {
+			return "", err
+		}
+		if check != code[len(code)-1] {
+			return "", ErrCodeChecksum
+		}
+	}
+
+	return formatCode(code, opts), nil
+}
+
+// codeAlphabet returns the alphabet of the options, after checking it and the separator.
+func codeAlphabet(opts CodeOptions) (string, error) {
+	alphabet := opts.Alphabet
+	if alphabet == "" {
+		alphabet = AlphabetCrockford
+	}
+	if err := checkAlphabet(alphabet); err != nil {
+		return "", err
+	}
+	if strings.ContainsAny(opts.Separator, alphabet) {
+		return "", ErrSeparatorInAlphabet
+	}
+	return alphabet, nil
+}
+
+// normalizeCodeRune changes the case of a char to the case of the alphabet, and replaces look-alikes.
+func normalizeCodeRune(r rune, alphabet string) rune {
+	if strings.ContainsRune(alphabet, r) {
+		return r
+	}
+	for _, c := range []rune{unicode.ToUpper(r), unicode.ToLower(r)} {
+		if strings.ContainsRune(alphabet, c) {
+			return c
+		}
+	}
+	for _, c := range codeLookAlikes[unicode.ToUpper(r)] {
+		for _, alike := range []rune{c, unicode.ToLower(c)} {
+			if strings.ContainsRune(alphabet, alike) {
+				return alike
+			}
+		}
+	}
+	return r
+}
+
+// formatCode splits a code into groups.
+func formatCode(code []rune, opts CodeOptions) string {
+	if opts.GroupSize <= 0 {
+		return string(code)
+	}
+
+	var b strings.Builder
+	for i, r := range code {
+		if i > 0 && i%opts.GroupSize == 0 {
+			b.WriteString(opts.Separator)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// codeCheckChar returns the check char of a code.
+func codeCheckChar(code, alphabet []rune, algorithm CheckAlgorithm) (rune, error) {
+	values := make([]int, len(code))
+	for i, r := range code {
+		values[i] = -1
+		for v, c := range alphabet {
+			if c == r {
+				values[i] = v
+				break
+			}
+		}
+		if values[i] < 0 {
+			return 0, ErrInvalidCode
+		}
+	}
+
+	switch algorithm {
+	case CheckLuhnModN:
+		if len(alphabet)%2 != 0 {
+			return 0, ErrUnsupportedAlphabet
+		}
+		return alphabet[luhnModN(values, len(alphabet))], nil
+	case CheckDamm:
+		check, err := damm(values, len(alphabet))
+		if err != nil {
+			return 0, err
+		}
+		return alphabet[check], nil
+	}
+	return 0, ErrInvalidCode
+}
+
+// luhnModN returns the Luhn mod N check value of the values.
+// Starting from the right, every other value is doubled, and the digits of the result in base n are added up.
+// Only an even n gives a different sum for each doubled value.
+func luhnModN(values []int, n int) int {
+	factor := 2
+	sum := 0
+	for i := len(values) - 1; i >= 0; i-- {
+		addend := factor * values[i]
+		sum += addend/n + addend%n
+		factor = 3 - factor
+	}
+	return (n - sum%n) % n
+}
+
+// damm returns the Damm check value of the values, using a quasigroup of order n.
+func damm(values []int, n int) (int, error) {
+	var table func(x, y int) int
+	if n == 10 {
+		table = func(x, y int) int { return dammTable10[x][y] }
+	} else if polynomial, ok := dammPolynomials[n]; ok {
+		// x*y = 2x + y in GF(n), which is totally anti-symmetric, as 2 is neither 0 nor 1
+		table = func(x, y int) int {
+			x <<= 1
+			if x >= n {
+				x ^= polynomial
+			}
+			return x ^ y
+		}
+	} else {
+		return 0, ErrUnsupportedAlphabet
+	}
+
+	interim := 0
+	for _, v := range values {
+		interim = table(interim, v)
+	}
+
+	// The check value makes the interim 0
+	for check := 0; check < n; check++ {
+		if table(interim, check) == 0 {
+			return check, nil
+		}
+	}
+	return 0, ErrUnsupportedAlphabet
+}
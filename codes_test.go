@@ -0,0 +1,167 @@
+package texttools
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGenerateCode(t *testing.T) {
+	reCode := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{4}-[0-9A-HJKMNP-TV-Z]{4}-[0-9A-HJKMNP-TV-Z]{4}$`)
+	for _, check := range []CheckAlgorithm{CheckNone, CheckLuhnModN, CheckDamm} {
+		opts := DefaultCodeOptions()
+		opts.Check = check
+
+		for i := 0; i < 50; i++ {
+			code, err := GenerateCode(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reCode.MatchString(code) {
+				t.Errorf("got %q, expected 3 groups of 4 chars from Crockford's base32", code)
+			}
+			if parsed, err := ParseCode(code, opts); err != nil || parsed != code {
+				t.Errorf("got %q and error %v from %q, expected the same code", parsed, err, code)
+			}
+		}
+	}
+
+	opts := CodeOptions{Length: 8, Alphabet: AlphabetNumeric, Check: CheckDamm}
+	if code, err := GenerateCode(opts); err != nil || len(code) != 8 {
+		t.Errorf("got %q and error %v, expected 8 digits", code, err)
+	}
+	opts = CodeOptions{Length: 8, Alphabet: AlphabetUnambiguous, Check: CheckDamm}
+	if _, err := GenerateCode(opts); err != ErrUnsupportedAlphabet {
+		t.Errorf("got error %v from Damm with %d chars, expected %v", err, len(AlphabetUnambiguous), ErrUnsupportedAlphabet)
+	}
+	if _, err := GenerateCode(CodeOptions{Length: 1, Check: CheckLuhnModN}); err != ErrInvalidCode {
+		t.Errorf("got error %v from a code with only a check char, expected %v", err, ErrInvalidCode)
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	opts := DefaultCodeOptions()
+	code := "ABCD-EFGH-JKM"
+	check, _ := codeCheckChar([]rune("ABCDEFGHJKM"), []rune(AlphabetCrockford), CheckLuhnModN)
+	code += string(check)
+
+	samples := []struct {
+		in, out string
+		err     error
+	}{
+		{code, code, nil},
+		{"abcd efgh jkm" + string(check), code, nil},
+		{" ABCDEFGHJKM" + string(check) + " ", code, nil},
+		{"ABCD-EFGH-JKM-" + string(check), code, nil},
+		{"ABCD-EFGH-JKM", "", ErrInvalidCode},
+		{"ABCD-EFGH-JKMU", "", ErrInvalidCode},
+		{"ABCD-EFGH-JKN" + string(check), "", ErrCodeChecksum},
+		{"ABCD-EFHG-JKM" + string(check), "", ErrCodeChecksum},
+	}
+
+	for _, sample := range samples {
+		out, err := ParseCode(sample.in, opts)
+		if out != sample.out || err != sample.err {
+			t.Errorf("got %q and error %v from %q, expected %q and error %v", out, err, sample.in, sample.out, sample.err)
+		}
+	}
+
+	// Look-alikes are replaced by the chars in the alphabet
+	opts = CodeOptions{Length: 6, Alphabet: AlphabetCrockford}
+	if out, err := ParseCode("oOiIlL", opts); out != "001111" || err != nil {
+		t.Errorf("got %q and error %v, expected 001111", out, err)
+	}
+	opts = CodeOptions{Length: 4, Alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZ"}
+	if out, err := ParseCode("0158", opts); out != "OISB" || err != nil {
+		t.Errorf("got %q and error %v, expected OISB", out, err)
+	}
+
+	// Dashes in the alphabet are kept
+	opts = CodeOptions{Length: 4, Alphabet: "AB-_"}
+	if out, err := ParseCode("A-B_", opts); out != "A-B_" || err != nil {
+		t.Errorf("got %q and error %v, expected A-B_", out, err)
+	}
+}
+
+func TestCodeOptionsValidation(t *testing.T) {
+	samples := []struct {
+		opts CodeOptions
+		err  error
+	}{
+		{CodeOptions{Length: 8, GroupSize: 4, Separator: "X"}, ErrSeparatorInAlphabet},
+		{CodeOptions{Length: 8, GroupSize: 4, Separator: " / 0"}, ErrSeparatorInAlphabet},
+		{CodeOptions{Length: 4, Alphabet: "AAB"}, ErrDuplicateChar},
+		{CodeOptions{Length: 4, Alphabet: "AB\xff"}, ErrInvalidUTF8},
+	}
+
+	for _, sample := range samples {
+		if _, err := GenerateCode(sample.opts); err != sample.err {
+			t.Errorf("got error %v from GenerateCode with %+v, expected %v", err, sample.opts, sample.err)
+		}
+		if _, err := ParseCode("ABCDEFGH", sample.opts); err != sample.err {
+			t.Errorf("got error %v from ParseCode with %+v, expected %v", err, sample.opts, sample.err)
+		}
+	}
+}
+
+func TestCodeCheckChar(t *testing.T) {
+	samples := []struct {
+		algorithm CheckAlgorithm
+		alphabet  string
+		in, out   string
+	}{
+		{CheckLuhnModN, AlphabetNumeric, "7992739871", "3"}, // The Luhn algorithm
+		{CheckLuhnModN, AlphabetNumeric, "411111111111111", "1"},
+		{CheckLuhnModN, "abcdef", "abcdef", "e"}, // The Luhn mod N example
+		{CheckDamm, AlphabetNumeric, "572", "4"},
+		{CheckDamm, AlphabetNumeric, "5724", "0"},
+	}
+
+	for _, sample := range samples {
+		check, err := codeCheckChar([]rune(sample.in), []rune(sample.alphabet), sample.algorithm)
+		if err != nil || string(check) != sample.out {
+			t.Errorf("got %q and error %v from %q, expected %q", check, err, sample.in, sample.out)
+		}
+	}
+}
+
+func TestCodeCheckCharDetectsErrors(t *testing.T) {
+	// Damm detects all single char errors and swaps of adjacent chars, Luhn mod N all single char errors
+	for _, alphabet := range []string{AlphabetNumeric, AlphabetHex, AlphabetCrockford, AlphabetURLSafe, AlphabetUnambiguous} {
+		chars := []rune(alphabet)
+		code := []rune(RandomStringFrom(10, alphabet))
+
+		for _, algorithm := range []CheckAlgorithm{CheckLuhnModN, CheckDamm} {
+			check, err := codeCheckChar(code, chars, algorithm)
+			// Neither algorithm supports an odd number of chars, like the 57 of AlphabetUnambiguous
+			if len(chars)%2 != 0 {
+				if err != ErrUnsupportedAlphabet {
+					t.Errorf("got error %v with %d chars, expected %v", err, len(chars), ErrUnsupportedAlphabet)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range code {
+				for _, c := range chars {
+					if c == code[i] {
+						continue
+					}
+					changed := append([]rune{}, code...)
+					changed[i] = c
+					if other, _ := codeCheckChar(changed, chars, algorithm); other == check {
+						t.Errorf("got the same check char for %q and %q", string(code), string(changed))
+					}
+				}
+
+				if algorithm == CheckDamm && i > 0 && code[i] != code[i-1] {
+					swapped := append([]rune{}, code...)
+					swapped[i], swapped[i-1] = swapped[i-1], swapped[i]
+					if other, _ := codeCheckChar(swapped, chars, algorithm); other == check {
+						t.Errorf("got the same check char for %q and %q", string(code), string(swapped))
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package texttools
+
+import (
+	_ "embed" // For the confusables data
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// The confusable mappings of UTS #39, from the Unicode confusables.txt data file.
+// Each line maps a char to the prototype it looks like, as hex code points: "0441 ;\t0063 ;\tMA".
+//
+//go:embed confusables.txt
+var confusablesData string
+
+// The confusable chars and their prototypes, which are parsed on first use.
+var confusablePrototypes = struct {
+	once       sync.Once
+	prototypes map[rune]string
+}{}
+
+// Scripts that can be mixed in an identifier, as they are used together, like in Japanese.
+var allowedScriptCombinations = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// Skeleton returns the confusable skeleton of a string, as defined by UTS #39.
+// Strings that look alike, like "paypal" and "pаypal" with a Cyrillic а, have the same skeleton.
+// The string is normalized to NFD, default ignorable chars, like zero width spaces, are removed,
+// each char is replaced with its prototype from the Unicode confusables data, and the result is normalized to NFD again.
+// The skeleton is only meant for comparing, not for showing.
+func Skeleton(str string) string {
+	str = norm.NFD.String(str)
+	prototypes := confusablesTable()
+
+	var b strings.Builder
+	b.Grow(len(str))
+	for _, r := range str {
+		if isDefaultIgnorable(r) {
+			continue
+		}
+		if prototype, ok := prototypes[r]; ok {
+			b.WriteString(prototype)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return norm.NFD.String(b.String())
+}
+
+// Confusable reports whether two strings look alike, i.e. they have the same skeleton.
+func Confusable(a, b string) bool {
+	return Skeleton(a) == Skeleton(b)
+}
+
+// Scripts returns the names of the scripts used in a string, like "Latin" and "Cyrillic", sorted.
+// Chars that are used by many scripts, like digits, punctuation and combining marks, are not included.
+func Scripts(str string) (scripts []string) {
+	seen := map[string]bool{}
+	for _, r := range str {
+		if script := runeScript(r); script != "" && !seen[script] {
+			seen[script] = true
+			scripts = append(scripts, script)
+		}
+	}
+	sort.Strings(scripts)
+	return
+}
+
+// IsMixedScript reports whether a string mixes scripts, like Latin and Cyrillic in "pаypal".
+// Scripts that are normally used together, like Han, Hiragana and Katakana in Japanese, are not considered mixed.
+func IsMixedScript(str string) bool {
+	scripts := Scripts(str)
+	if len(scripts) <= 1 {
+		return false
+	}
+	for _, allowed := range allowedScriptCombinations {
+		// Latin is not part of the combinations here, only in identifiers
+		if allScriptsIn(scripts, allowed[1:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsRestrictedIdentifier reports whether a string is a safe identifier, like a username,
+// following the "highly restrictive" level of UTS #39.
+// It must only contain letters, marks, digits, "_", "-" and ".", be NFKC normalized,
+// have no invisible chars, use digits from only one numbering system,
+// and use only one script, or Latin together with the scripts of Japanese, Chinese or Korean.
+func IsRestrictedIdentifier(str string) bool {
+	if str == "" || norm.NFKC.String(str) != str {
+		return false
+	}
+
+	digitZero := rune(-1)
+	for _, r := range str {
+		switch {
+		case invisibleCategory(r) != 0:
+			return false
+		case unicode.Is(unicode.Nd, r):
+			zero := digitZeroOf(r)
+			if digitZero >= 0 && zero != digitZero {
+				return false
+			}
+			digitZero = zero
+		case unicode.IsLetter(r), unicode.IsMark(r), r == '_', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+
+	scripts := Scripts(str)
+	if len(scripts) <= 1 {
+		return true
+	}
+	for _, allowed := range allowedScriptCombinations {
+		if allScriptsIn(scripts, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// confusablesTable returns the confusable chars and their prototypes.
+func confusablesTable() map[rune]string {
+	confusablePrototypes.once.Do(func() {
+		confusablePrototypes.prototypes = make(map[rune]string, 6600)
+		for _, line := range strings.Split(confusablesData, "\n") {
+			fields := strings.Split(line, ";")
+			if strings.HasPrefix(line, "#") || len(fields) < 3 {
+				continue
+			}
+			source, target := parseCodePoints(fields[0]), parseCodePoints(fields[1])
+			if len(source) == 1 {
+				confusablePrototypes.prototypes[source[0]] = string(target)
+			}
+		}
+	})
+	return confusablePrototypes.prototypes
+}
+
+// parseCodePoints parses code points written as hex numbers separated by spaces, like "0072 006E".
+func parseCodePoints(str string) (runes []rune) {
+	for _, field := range strings.Fields(str) {
+		if cp, err := strconv.ParseUint(field, 16, 32); err == nil {
+			runes = append(runes, rune(cp))
+		}
+	}
+	return
+}
+
+// isDefaultIgnorable reports whether a char has the Unicode Default_Ignorable_Code_Point property,
+// which is derived from format chars, variation selectors and a few others, like the Hangul fillers.
+func isDefaultIgnorable(r rune) bool {
+	if unicode.In(r, unicode.Other_Default_Ignorable_Code_Point, unicode.Variation_Selector) {
+		return true
+	}
+	return unicode.Is(unicode.Cf, r) && !unicode.In(r, unicode.White_Space, unicode.Prepended_Concatenation_Mark) &&
+		!(r >= 0xFFF9 && r <= 0xFFFB) && !(r >= 0x13430 && r <= 0x1343F)
+}
+
+// runeScript returns the name of the script of a rune, or "" for chars used by many scripts.
+func runeScript(r rune) string {
+	if r < 0x80 {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return "Latin"
+		}
+		return ""
+	}
+	for name, table := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" && unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
+
+// digitZeroOf returns the zero of the numbering system of a decimal digit.
+// Decimal digits are always in runs of 10, starting with zero, but some runs follow right after each other.
+func digitZeroOf(r rune) rune {
+	start := r
+	for unicode.Is(unicode.Nd, start-1) {
+		start--
+	}
+	return start + (r-start)/10*10
+}
+
+// allScriptsIn reports whether all the scripts are in the allowed scripts.
+func allScriptsIn(scripts, allowed []string) bool {
+	for _, script := range scripts {
+		if !StringInSlice(script, allowed) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package texttools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfusable(t *testing.T) {
+	confusable := [][2]string{
+		{"paypal", "pаypal"},     // Cyrillic а
+		{"apple", "аррlе"},       // Cyrillic а, р and е
+		{"google", "gооgle"},     // Cyrillic о
+		{"PAYPAL", "РΑYPΑL"},     // Cyrillic Р and Greek Α
+		{"admin", "𝐚𝐝𝐦𝐢𝐧"},       // Mathematical bold
+		{"BOB", "ꓐꓳꓐ"},           // Lisu
+		{"ABC", "ᎪᏴᏟ"},           // Cherokee
+		{"ok", "օk"},             // Armenian օ
+		{"admin", "adm\u200bin"}, // Zero width space
+		{"modern", "rnodern"},
+		{"Il1|", "llll"},
+		{"O0", "OO"},
+		{"micro-soft", "micro‐soft"},
+		{"café", "cafe\u0301"},
+	}
+	for _, pair := range confusable {
+		if !Confusable(pair[0], pair[1]) {
+			t.Errorf("expected %q (%q) and %q (%q) to be confusable", pair[0], Skeleton(pair[0]), pair[1], Skeleton(pair[1]))
+		}
+	}
+
+	different := [][2]string{
+		{"paypal", "paypa1x"},
+		{"cafe", "café"},
+		{"abc", "ABC"},
+	}
+	for _, pair := range different {
+		if Confusable(pair[0], pair[1]) {
+			t.Errorf("expected %q and %q not to be confusable, both have the skeleton %q", pair[0], pair[1], Skeleton(pair[0]))
+		}
+	}
+}
+
+func TestScripts(t *testing.T) {
+	samples := []struct {
+		in      string
+		scripts []string
+		mixed   bool
+	}{
+		{"paypal", []string{"Latin"}, false},
+		{"pаypal", []string{"Cyrillic", "Latin"}, true},
+		{"Ελληνικά 123!", []string{"Greek"}, false},
+		{"東京タワーとうきょう", []string{"Han", "Hiragana", "Katakana"}, false},
+		{"서울特別市", []string{"Han", "Hangul"}, false},
+		{"Tokyo東京", []string{"Han", "Latin"}, true},
+		{"123 !?", nil, false},
+	}
+
+	for _, sample := range samples {
+		if scripts := Scripts(sample.in); !reflect.DeepEqual(scripts, sample.scripts) {
+			t.Errorf("got %v from %q, expected %v", scripts, sample.in, sample.scripts)
+		}
+		if mixed := IsMixedScript(sample.in); mixed != sample.mixed {
+			t.Errorf("got %t from %q, expected %t", mixed, sample.in, sample.mixed)
+		}
+	}
+}
+
+func TestIsRestrictedIdentifier(t *testing.T) {
+	samples := []struct {
+		in         string
+		restricted bool
+	}{
+		{"paypal", true},
+		{"john.doe_42-x", true},
+		{"Ærøskøbing", true},
+		{"Ελληνικά", true},
+		{"Tokyo東京タワー", true},
+		{"ソウル서울", false},
+		{"pаypal", false},
+		{"adm\u200bin", false},
+		{"ａdmin", false},
+		{"john doe", false},
+		{"john@doe", false},
+		{"12٣", false},
+		{"١٢٣", true},
+		{"", false},
+	}
+
+	for _, sample := range samples {
+		if restricted := IsRestrictedIdentifier(sample.in); restricted != sample.restricted {
+			t.Errorf("got %t from %q, expected %t", restricted, sample.in, sample.restricted)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package texttools
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// EscapeDialect is a way of escaping strings with backslashes.
+type EscapeDialect int
+
+// The supported dialects.
+const (
+	DialectPHP   EscapeDialect = iota // PHP addslashes and stripslashes
+	DialectMySQL                      // MySQL string literals, like in dumps
+	DialectJSON                       // JSON strings
+	DialectGo                         // Go interpreted string literals
+	DialectC                          // C string literals
+)
+
+// Errors returned when unescaping strings.
+var (
+	ErrInvalidEscape  = errors.New("texttools: invalid escape sequence")
+	ErrUnknownDialect = errors.New("texttools: unknown escape dialect")
+)
+
+// The escapes that are a backslash followed by a single char, and the chars they represent.
+var (
+	mysqlEscapes = map[byte]string{'0': "\x00", 'b': "\b", 'n': "\n", 'r': "\r", 't': "\t", 'Z': "\x1a", '%': `\%`, '_': `\_`}
+	jsonEscapes  = map[byte]string{'"': `"`, '\\': `\`, '/': "/", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t"}
+	cEscapes     = map[byte]string{'a': "\a", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v", '\\': `\`, '\'': "'", '"': `"`, '?': "?"}
+)
+
+var (
+	phpEscaper   = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `"`, `\"`, "\x00", `\0`)
+	mysqlEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `"`, `\"`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+)
+
+// EscapeString escapes a string with backslashes, like the dialect does.
+// The result is not quoted.
+func EscapeString(str string, dialect EscapeDialect) (string, error) {
+	switch dialect {
+	case DialectPHP:
+		return phpEscaper.Replace(str), nil
+	case DialectMySQL:
+		return mysqlEscaper.Replace(str), nil
+	case DialectJSON:
+		return escapeJSON(str), nil
+	case DialectGo:
+		quoted := strconv.Quote(str)
+		return quoted[1 : len(quoted)-1], nil
+	case DialectC:
+		return escapeC(str), nil
+	}
+	return "", ErrUnknownDialect
+}
+
+// UnescapeString removes the backslash escapes of the dialect from a string in a single pass,
+// so e.g. `\\n` becomes a backslash followed by n.
+// The string must not be quoted.
+// Malformed escapes return ErrInvalidEscape, and a backslash at the end returns ErrTruncatedInput.
+// PHP and MySQL accept any char after a backslash, like stripslashes and MySQL do.
+func UnescapeString(str string, dialect EscapeDialect) (string, error) {
+	if dialect < DialectPHP || dialect > DialectC {
+		return "", ErrUnknownDialect
+	}
+
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for i := 0; i < len(str); {
+		j := strings.IndexByte(str[i:], '\\')
+		if j < 0 {
+			b.WriteString(str[i:])
+			break
+		}
+		b.WriteString(str[i : i+j])
+		i += j
+
+		if i+1 == len(str) {
+			return "", fmt.Errorf("%w at byte %d", ErrTruncatedInput, i)
+		}
+		n, err := unescapeSequence(&b, str[i:], dialect)
+		if err != nil {
+			return "", fmt.Errorf("%w at byte %d", err, i)
+		}
+		i += n
+	}
+
+	return b.String(), nil
+}
+
+// unescapeSequence writes the char of the escape sequence at the start of str, which starts with a backslash,
+// and returns the length of the sequence.
+func unescapeSequence(b *strings.Builder, str string, dialect EscapeDialect) (int, error) {
+	c := str[1]
+
+	switch dialect {
+	case DialectPHP:
+		if c == '0' {
+			b.WriteByte(0)
+		} else {
+			b.WriteByte(c)
+		}
+		return 2, nil
+
+	case DialectMySQL:
+		if esc, ok := mysqlEscapes[c]; ok {
+			b.WriteString(esc)
+		} else {
+			b.WriteByte(c)
+		}
+		return 2, nil
+
+	case DialectJSON:
+		if esc, ok := jsonEscapes[c]; ok {
+			b.WriteString(esc)
+			return 2, nil
+		}
+		if c != 'u' {
+			return 0, ErrInvalidEscape
+		}
+		r, err := hexEscape(str, 2, 4)
+		if err != nil {
+			return 0, err
+		}
+		if !utf16.IsSurrogate(r) {
+			b.WriteRune(r)
+			return 6, nil
+		}
+		// A surrogate must be the high half of a pair, followed by the low half
+		if !strings.HasPrefix(str[6:], `\u`) {
+			return 0, ErrUnpairedSurrogate
+		}
+		low, err := hexEscape(str, 8, 4)
+		if err != nil {
+			return 0, err
+		}
+		if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+			return 0, ErrUnpairedSurrogate
+		}
+		b.WriteRune(r)
+		return 12, nil
+
+	case DialectGo:
+		value, multibyte, tail, err := strconv.UnquoteChar(str, '"')
+		if err != nil {
+			return 0, ErrInvalidEscape
+		}
+		if multibyte {
+			b.WriteRune(value)
+		} else {
+			// Octal and \x escapes are bytes, not chars
+			b.WriteByte(byte(value))
+		}
+		return len(str) - len(tail), nil
+
+	case DialectC:
+		if esc, ok := cEscapes[c]; ok {
+			b.WriteString(esc)
+			return 2, nil
+		}
+
+		switch {
+		case c >= '0' && c <= '7':
+			// 1 to 3 octal digits
+			n := 1
+			for n < 4 && n < len(str) && str[n] >= '0' && str[n] <= '7' {
+				n++
+			}
+			v, _ := strconv.ParseUint(str[1:n], 8, 16)
+			if v > 0xff {
+				return 0, ErrInvalidEscape
+			}
+			b.WriteByte(byte(v))
+			return n, nil
+
+		case c == 'x':
+			// Any number of hex digits
+			n := 2
+			for n < len(str) && isHexDigit(str[n]) {
+				n++
+			}
+			v, err := strconv.ParseUint(str[2:n], 16, 8)
+			if err != nil {
+				return 0, ErrInvalidEscape
+			}
+			b.WriteByte(byte(v))
+			return n, nil
+
+		case c == 'u' || c == 'U':
+			digits := 4
+			if c == 'U' {
+				digits = 8
+			}
+			r, err := hexEscape(str, 2, digits)
+			if err != nil {
+				return 0, err
+			}
+			if !utf8.ValidRune(r) {
+				return 0, ErrInvalidEscape
+			}
+			b.WriteRune(r)
+			return 2 + digits, nil
+		}
+		return 0, ErrInvalidEscape
+	}
+
+	return 0, ErrUnknownDialect
+}
+
+// hexEscape parses the hex digits of an escape like \uXXXX, starting at str[start].
+func hexEscape(str string, start, digits int) (rune, error) {
+	if len(str) < start+digits {
+		return 0, ErrTruncatedInput
+	}
+	v, err := strconv.ParseUint(str[start:start+digits], 16, 32)
+	if err != nil {
+		return 0, ErrInvalidEscape
+	}
+	return rune(v), nil
+}
+
+// isHexDigit reports whether c is a hex digit.
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// escapeJSON escapes a string like JSON does.
+// U+2028 and U+2029 are also escaped, as they are line breaks in JavaScript, and invalid UTF-8 becomes \ufffd.
+func escapeJSON(str string) string {
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for _, r := range str {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == '\u2028' || r == '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		case r == utf8.RuneError:
+			b.WriteString(`\ufffd`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// escapeC escapes a string like a C string literal.
+// Control chars without a short escape are written as 3 digit octal escapes, and all other bytes are kept as they are.
+func escapeC(str string) string {
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch c {
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\%03o`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package texttools
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestUnescapeString(t *testing.T) {
+	samples := []struct {
+		dialect EscapeDialect
+		in, out string
+	}{
+		{DialectPHP, `It\'s a \"quote\", a \\ and \\\"`, `It's a "quote", a \ and \"`},
+		{DialectPHP, `\0\n\x`, "\x00nx"},
+		{DialectMySQL, `It\'s\n\ta\r\Z\0\\ \%\_ \q`, "It's\n\ta\r\x1a\x00\\ \\%\\_ q"},
+		{DialectJSON, `\"a\"\\\/\b\f\n\r\t\u00e6\u00E6 \ud83d\ude00`, "\"a\"\\/\b\f\n\r\tææ 😀"},
+		{DialectGo, `\a\b\f\n\r\t\v\\\"\101\x41\u00e6\U0001F600`, "\a\b\f\n\r\t\v\\\"AAæ😀"},
+		{DialectGo, `\377\xff`, "\xff\xff"},
+		{DialectC, `\a\b\f\n\r\t\v\\\'\"\?`, "\a\b\f\n\r\t\v\\'\"?"},
+		{DialectC, `\0\101\7x\x41\x4g\u00e6\U0001F600`, "\x00A\ax" + "A\x04gæ😀"},
+		{DialectJSON, "No escapes æ", "No escapes æ"},
+	}
+
+	for _, sample := range samples {
+		out, err := UnescapeString(sample.in, sample.dialect)
+		if err != nil {
+			t.Errorf("got error %q from %q with dialect %d", err, sample.in, sample.dialect)
+		} else if out != sample.out {
+			t.Errorf("got %q from %q with dialect %d, expected %q", out, sample.in, sample.dialect, sample.out)
+		}
+	}
+}
+
+func TestUnescapeStringErrors(t *testing.T) {
+	samples := []struct {
+		dialect EscapeDialect
+		in      string
+		err     error
+	}{
+		{DialectPHP, `abc\`, ErrTruncatedInput},
+		{DialectMySQL, `\\\`, ErrTruncatedInput},
+		{DialectJSON, `\x41`, ErrInvalidEscape},
+		{DialectJSON, `\'`, ErrInvalidEscape},
+		{DialectJSON, `\u12`, ErrTruncatedInput},
+		{DialectJSON, `\u12g4`, ErrInvalidEscape},
+		{DialectJSON, `\ud83d`, ErrUnpairedSurrogate},
+		{DialectJSON, `\ud83dx`, ErrUnpairedSurrogate},
+		{DialectJSON, `\ud83d\u0041`, ErrUnpairedSurrogate},
+		{DialectJSON, `\ude00`, ErrUnpairedSurrogate},
+		{DialectGo, `\'`, ErrInvalidEscape},
+		{DialectGo, `\400`, ErrInvalidEscape},
+		{DialectGo, `\x4`, ErrInvalidEscape},
+		{DialectGo, `\ud800`, ErrInvalidEscape},
+		{DialectGo, `\q`, ErrInvalidEscape},
+		{DialectC, `\777`, ErrInvalidEscape},
+		{DialectC, `\x`, ErrInvalidEscape},
+		{DialectC, `\x100`, ErrInvalidEscape},
+		{DialectC, `\U00110000`, ErrInvalidEscape},
+		{DialectC, `\q`, ErrInvalidEscape},
+		{EscapeDialect(99), `abc`, ErrUnknownDialect},
+	}
+
+	for _, sample := range samples {
+		if _, err := UnescapeString(sample.in, sample.dialect); !errors.Is(err, sample.err) {
+			t.Errorf("got error %v from %q with dialect %d, expected %q", err, sample.in, sample.dialect, sample.err)
+		}
+	}
+}
+
+func TestEscapeString(t *testing.T) {
+	in := "It's \"a\" \\ \x00\n\r\t\x1a\x7f æ\u2028😀"
+	samples := []struct {
+		dialect EscapeDialect
+		out     string
+	}{
+		{DialectPHP, "It\\'s \\\"a\\\" \\\\ \\0\n\r\t\x1a\x7f æ\u2028😀"},
+		{DialectMySQL, "It\\'s \\\"a\\\" \\\\ \\0\\n\\r\t\\Z\x7f æ\u2028😀"},
+		{DialectJSON, `It's \"a\" \\ \u0000\n\r\t\u001a` + "\x7f æ" + `\u2028` + "😀"},
+		{DialectGo, `It's \"a\" \\ \x00\n\r\t\x1a\x7f æ\u2028😀`},
+		{DialectC, `It's \"a\" \\ \000\n\r\t\032\177 æ` + "\u2028😀"},
+	}
+
+	for _, sample := range samples {
+		out, err := EscapeString(in, sample.dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != sample.out {
+			t.Errorf("got %q from %q with dialect %d, expected %q", out, in, sample.dialect, sample.out)
+		}
+
+		// Escaping and unescaping must give the original string
+		if back, err := UnescapeString(out, sample.dialect); err != nil || back != in {
+			t.Errorf("got %q, %v when unescaping %q with dialect %d, expected %q", back, err, out, sample.dialect, in)
+		}
+	}
+
+	// The JSON and Go escapes must be understood by the standard library
+	out, _ := EscapeString(in, DialectJSON)
+	var fromJSON string
+	if err := json.Unmarshal([]byte(`"`+out+`"`), &fromJSON); err != nil || fromJSON != in {
+		t.Errorf("got %q, %v when decoding %q as JSON, expected %q", fromJSON, err, out, in)
+	}
+	out, _ = EscapeString(in, DialectGo)
+	if fromGo, err := strconv.Unquote(`"` + out + `"`); err != nil || fromGo != in {
+		t.Errorf("got %q, %v when unquoting %q, expected %q", fromGo, err, out, in)
+	}
+
+	if _, err := EscapeString(in, EscapeDialect(-1)); !errors.Is(err, ErrUnknownDialect) {
+		t.Errorf("got error %v, expected %q", err, ErrUnknownDialect)
+	}
+}
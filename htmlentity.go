@@ -0,0 +1,76 @@
+package texttools
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HTMLEntityMode controls which chars EncodeHTMLEntities encodes.
+// The modes can be combined, e.g. HTMLEntityASCII|HTMLEntityAttribute.
+type HTMLEntityMode int
+
+// The encoding modes.
+// All modes encode &, < and >.
+const (
+	HTMLEntityMinimal   HTMLEntityMode = 0 // Only &, < and >, which is enough for text content
+	HTMLEntityAttribute HTMLEntityMode = 1 // Also " and ', for attribute values
+	HTMLEntityASCII     HTMLEntityMode = 2 // Also all non-ASCII chars, as numeric entities
+)
+
+var reEmptyHexEntity = regexp.MustCompile(`&#[xX];`)
+
+// DecodeHTMLEntities decodes all HTML entities, like "&eacute;", "&#8217;" and "&#x2019;", to UTF-8.
+// All HTML5 named entities are decoded, as well as the legacy forms without a semicolon that browsers accept,
+// like "&copy" and "&#169".
+// Numeric entities are decoded like browsers do, e.g. "&#150;" is the cp1252 dash and invalid code points are replaced by U+FFFD.
+func DecodeHTMLEntities(str string) string {
+	// html.UnescapeString decodes "&#x;" as U+FFFD, but browsers keep it as is
+	var b strings.Builder
+	last := 0
+	for _, m := range reEmptyHexEntity.FindAllStringIndex(str, -1) {
+		b.WriteString(html.UnescapeString(str[last:m[0]]))
+		b.WriteString(str[m[0]:m[1]])
+		last = m[1]
+	}
+	if last == 0 {
+		return html.UnescapeString(str)
+	}
+	b.WriteString(html.UnescapeString(str[last:]))
+	return b.String()
+}
+
+// EncodeHTMLEntities encodes the chars that have a special meaning in HTML as entities.
+// The mode controls which other chars are encoded as well.
+// Invalid UTF-8 is encoded as U+FFFD in ASCII mode.
+func EncodeHTMLEntities(str string, mode HTMLEntityMode) string {
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for i, r := range str {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"' && mode&HTMLEntityAttribute != 0:
+			b.WriteString("&quot;")
+		case r == '\'' && mode&HTMLEntityAttribute != 0:
+			b.WriteString("&#39;")
+		case r >= utf8.RuneSelf && mode&HTMLEntityASCII != 0:
+			b.WriteString("&#" + strconv.Itoa(int(r)) + ";")
+		case r == utf8.RuneError:
+			// Keep invalid UTF-8 as is
+			_, size := utf8.DecodeRuneInString(str[i:])
+			b.WriteString(str[i : i+size])
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
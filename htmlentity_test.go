@@ -0,0 +1,50 @@
+package texttools
+
+import "testing"
+
+func TestDecodeHTMLEntities(t *testing.T) {
+	samples := []sample{
+		{"Caf&eacute; &amp; bar", "Café & bar"},
+		{"It&#8217;s &#x2019;quoted&#X2019;", "It’s ’quoted’"},
+		{"a&nbsp;b", "a b"},
+		{"&copy 2020 &amp co &lt;b&gt", "© 2020 & co <b>"},
+		{"&#169 &#xA9 &#65", "© © A"},
+		{"&notit; &notin;", "¬it; ∉"},
+		{"&NotNestedGreaterGreater; &fjlig; &bne;", "⪢̸ fj =⃥"},
+		{"&#150; &#128;", "– €"},
+		{"&#0; &#xD800; &#x110000;", "� � �"},
+		{"&unknown; & &; &#; &#x; &#X;x", "&unknown; & &; &#; &#x; &#X;x"},
+		{"No entities", "No entities"},
+	}
+
+	for _, sample := range samples {
+		if out := DecodeHTMLEntities(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestEncodeHTMLEntities(t *testing.T) {
+	in := `<a href="x">Tom & Jerry's café</a> ☃ 😀` + "\xff"
+	samples := []struct {
+		mode HTMLEntityMode
+		out  string
+	}{
+		{HTMLEntityMinimal, `&lt;a href="x"&gt;Tom &amp; Jerry's café&lt;/a&gt; ☃ 😀` + "\xff"},
+		{HTMLEntityAttribute, `&lt;a href=&quot;x&quot;&gt;Tom &amp; Jerry&#39;s café&lt;/a&gt; ☃ 😀` + "\xff"},
+		{HTMLEntityASCII, `&lt;a href="x"&gt;Tom &amp; Jerry's caf&#233;&lt;/a&gt; &#9731; &#128512;&#65533;`},
+		{HTMLEntityASCII | HTMLEntityAttribute, `&lt;a href=&quot;x&quot;&gt;Tom &amp; Jerry&#39;s caf&#233;&lt;/a&gt; &#9731; &#128512;&#65533;`},
+	}
+
+	for _, sample := range samples {
+		out := EncodeHTMLEntities(in, sample.mode)
+		if out != sample.out {
+			t.Errorf("got %q from %q with mode %d, expected %q", out, in, sample.mode, sample.out)
+		}
+		if sample.mode&HTMLEntityASCII == 0 {
+			if dec := DecodeHTMLEntities(out); dec != in {
+				t.Errorf("got %q when decoding %q, expected %q", dec, out, in)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package texttools
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLDocument is the text, links, images and metadata extracted from an HTML document by ExtractHTML.
+type HTMLDocument struct {
+	// Title is the content of the title element.
+	Title string
+
+	// Description is the content of the description meta tag.
+	Description string
+
+	// OpenGraph maps Open Graph properties, like "og:title" and "og:image", to their values.
+	// Properties can have multiple values, e.g. when a page has multiple images.
+	OpenGraph map[string][]string
+
+	// Links are the links in the document, in order.
+	Links []HTMLLink
+
+	// Images are the images in the document, in order.
+	Images []HTMLImage
+
+	// Text is the document converted to text, like HTMLToText does.
+	Text string
+}
+
+// HTMLLink is a link extracted from HTML.
+type HTMLLink struct {
+	URL  string
+	Text string
+	Rel  string
+}
+
+// HTMLImage is an image extracted from HTML.
+type HTMLImage struct {
+	URL string
+	Alt string
+}
+
+// Open Graph properties that contain URLs.
+var openGraphURLProperties = map[string]bool{
+	"og:url":              true,
+	"og:image":            true,
+	"og:image:url":        true,
+	"og:image:secure_url": true,
+	"og:video":            true,
+	"og:video:url":        true,
+	"og:video:secure_url": true,
+	"og:audio":            true,
+	"og:audio:url":        true,
+	"og:audio:secure_url": true,
+}
+
+// htmlExtractor collects links, images and metadata from a parsed document.
+type htmlExtractor struct {
+	doc  HTMLDocument
+	base *url.URL
+}
+
+// ExtractHTML parses HTML once and returns its text, links, images, title, description and Open Graph tags.
+// Relative URLs are resolved against baseURL, or against the base element of the document if it has one.
+// If baseURL is empty, relative URLs are only resolved against the base element.
+// Links with a javascript:, vbscript: or data: URL are left out.
+func ExtractHTML(str, baseURL string) (doc HTMLDocument, err error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return
+	}
+
+	root, err := html.Parse(strings.NewReader(str))
+	if err != nil {
+		return
+	}
+
+	e := &htmlExtractor{base: base}
+	if n := htmlFind(root, atom.Base); n != nil {
+		if href, err := url.Parse(strings.TrimSpace(htmlAttr(n, "href"))); err == nil {
+			e.base = base.ResolveReference(href)
+		}
+	}
+
+	e.doc.OpenGraph = map[string][]string{}
+	e.extract(root)
+	e.doc.Text = renderHTMLNodeText(root, HTMLTextOptions{MaxWidth: defaultHTMLTextWidth})
+
+	return e.doc, nil
+}
+
+// extract collects the links, images and metadata in a node and all of its children.
+func (e *htmlExtractor) extract(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Title:
+			if e.doc.Title == "" {
+				e.doc.Title = strings.Join(strings.Fields(htmlTextContent(n)), " ")
+			}
+			return
+
+		case atom.Meta:
+			content := strings.TrimSpace(htmlAttr(n, "content"))
+			if strings.ToLower(htmlAttr(n, "name")) == "description" && e.doc.Description == "" {
+				e.doc.Description = content
+			}
+			if property := strings.ToLower(strings.TrimSpace(htmlAttr(n, "property"))); strings.HasPrefix(property, "og:") && content != "" {
+				if openGraphURLProperties[property] {
+					content = e.resolve(content)
+				}
+				e.doc.OpenGraph[property] = append(e.doc.OpenGraph[property], content)
+			}
+
+		case atom.A, atom.Area:
+			href := strings.TrimSpace(htmlAttr(n, "href"))
+			if href == "" || !isSafeURL(href) {
+				break
+			}
+			e.doc.Links = append(e.doc.Links, HTMLLink{
+				URL:  e.resolve(href),
+				Text: strings.Join(strings.Fields(htmlTextContent(n)), " "),
+				Rel:  strings.TrimSpace(htmlAttr(n, "rel")),
+			})
+
+		case atom.Img:
+			if src := strings.TrimSpace(htmlAttr(n, "src")); src != "" {
+				e.doc.Images = append(e.doc.Images, HTMLImage{URL: e.resolve(src), Alt: strings.TrimSpace(htmlAttr(n, "alt"))})
+			}
+		}
+
+		if htmlSkipElements[n.DataAtom] && n.DataAtom != atom.Head {
+			return
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		e.extract(c)
+	}
+}
+
+// resolve resolves a URL against the base URL.
+// URLs that can't be parsed, or when there is no base URL, are returned as they are.
+func (e *htmlExtractor) resolve(ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || *e.base == (url.URL{}) {
+		return ref
+	}
+	return e.base.ResolveReference(u).String()
+}
+
+// isSafeURL reports whether a URL doesn't run scripts or contain inline data.
+func isSafeURL(ref string) bool {
+	u, err := url.Parse(reURLIgnored.ReplaceAllString(ref, ""))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "javascript", "vbscript", "data":
+		return false
+	}
+	return true
+}
+
+// htmlFind returns the first element of the given type, or nil if there is none.
+func htmlFind(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := htmlFind(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
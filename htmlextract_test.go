@@ -0,0 +1,86 @@
+package texttools
+
+import (
+	"reflect"
+	"testing"
+)
+
+const extractSample = `<!DOCTYPE html>
+<html>
+<head>
+	<title>
+		Sample   page
+	</title>
+	<meta name="Description" content=" A sample page. ">
+	<meta property="og:title" content="Sample">
+	<meta property="og:image" content="/img/one.png">
+	<meta property="og:image" content="https://cdn.example.com/two.png">
+	<meta property="og:empty" content="">
+	<script>var url = "<a href='/script'>";</script>
+</head>
+<body>
+	<h1>Sample</h1>
+	<p>Read <a href="/about">about us</a>, the <a href="docs/index.html" rel="nofollow">docs</a>
+	or <a href="https://other.example.org/">another <b>site</b></a>.</p>
+	<p><a href="javascript:alert(1)">Bad</a><a href="#top">Top</a><a>No href</a></p>
+	<img src="cat.jpg" alt="A cat"><img src="data:image/png;base64,AAAA"><img alt="No src">
+</body>
+</html>`
+
+func TestExtractHTML(t *testing.T) {
+	doc, err := ExtractHTML(extractSample, "https://example.com/blog/post.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := HTMLDocument{
+		Title:       "Sample page",
+		Description: "A sample page.",
+		OpenGraph: map[string][]string{
+			"og:title": {"Sample"},
+			"og:image": {"https://example.com/img/one.png", "https://cdn.example.com/two.png"},
+		},
+		Links: []HTMLLink{
+			{URL: "https://example.com/about", Text: "about us"},
+			{URL: "https://example.com/blog/docs/index.html", Text: "docs", Rel: "nofollow"},
+			{URL: "https://other.example.org/", Text: "another site"},
+			{URL: "https://example.com/blog/post.html#top", Text: "Top"},
+		},
+		Images: []HTMLImage{
+			{URL: "https://example.com/blog/cat.jpg", Alt: "A cat"},
+			{URL: "data:image/png;base64,AAAA"},
+		},
+		Text: "Sample\n\nRead about us, the docs or another site (https://other.example.org/).\n\nBadTopNo href\n\nA catNo src",
+	}
+
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("got %+v, expected %+v", doc, expected)
+	}
+}
+
+func TestExtractHTMLBase(t *testing.T) {
+	in := `<base href="/static/"><a href="page.html">Page</a><img src="//cdn.example.com/x.png">`
+	samples := []struct {
+		base, link, img string
+	}{
+		{"https://example.com/a/b", "https://example.com/static/page.html", "https://cdn.example.com/x.png"},
+		{"", "/static/page.html", "//cdn.example.com/x.png"},
+	}
+
+	for _, sample := range samples {
+		doc, err := ExtractHTML(in, sample.base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Links) != 1 || doc.Links[0].URL != sample.link {
+			t.Errorf("got links %+v with base %q, expected %q", doc.Links, sample.base, sample.link)
+		}
+		if len(doc.Images) != 1 || doc.Images[0].URL != sample.img {
+			t.Errorf("got images %+v with base %q, expected %q", doc.Images, sample.base, sample.img)
+		}
+	}
+
+	if _, err := ExtractHTML(in, "http://[::1"); err == nil {
+		t.Error("expected an error for an invalid base URL")
+	}
+}
@@ -0,0 +1,531 @@
+package texttools
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MarkdownHeadingStyle is the style used for headings by HTMLToMarkdownWithOptions.
+type MarkdownHeadingStyle int
+
+// The heading styles.
+// Setext headings are underlined with = or -, and only exist for h1 and h2,
+// so ATX headings are used for the rest.
+const (
+	HeadingATX    MarkdownHeadingStyle = iota // # Heading
+	HeadingSetext                             // Heading\n=======
+)
+
+// MarkdownLinkStyle is the style used for links and images by HTMLToMarkdownWithOptions.
+type MarkdownLinkStyle int
+
+// The link styles.
+const (
+	LinkInline    MarkdownLinkStyle = iota // [text](url)
+	LinkReference                          // [text][1] with [1]: url at the end
+)
+
+// MarkdownOptions controls how HTMLToMarkdownWithOptions renders HTML.
+type MarkdownOptions struct {
+	HeadingStyle MarkdownHeadingStyle
+	LinkStyle    MarkdownLinkStyle
+}
+
+var (
+	reMarkdownOrderedListStart = regexp.MustCompile(`^(\d+)([.)])`)
+	reMarkdownLanguage         = regexp.MustCompile(`(?:^|\s)(?:language|lang)-(\S+)`)
+	reBacktickRuns             = regexp.MustCompile("`+")
+)
+
+// markdownEscaper escapes chars that have a meaning anywhere in Markdown text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`~`, `\~`,
+	`&`, `&amp;`,
+)
+
+// markdownWriter renders a parsed HTML tree as Markdown.
+type markdownWriter struct {
+	blockWriter
+	opts    MarkdownOptions
+	refs    *[]string // URLs of reference style links, shared with the writers of inline content
+	inTable bool
+}
+
+// HTMLToMarkdown converts HTML to Markdown, with ATX headings and inline links.
+// Headings, emphasis, links, images, lists, code, blockquotes and tables (GFM) are kept,
+// and chars with a meaning in Markdown are escaped.
+func HTMLToMarkdown(html string) string {
+	return HTMLToMarkdownWithOptions(html, MarkdownOptions{})
+}
+
+// HTMLToMarkdownWithOptions converts HTML to Markdown like HTMLToMarkdown, but with control over
+// the heading and link styles.
+func HTMLToMarkdownWithOptions(str string, opts MarkdownOptions) string {
+	doc, err := html.Parse(strings.NewReader(str))
+	if err != nil {
+		return ""
+	}
+
+	var refs []string
+	w := &markdownWriter{blockWriter: blockWriter{lineStart: true}, opts: opts, refs: &refs}
+	w.render(doc)
+
+	// Reference style links are listed at the end
+	if len(refs) > 0 {
+		w.blockBreak(2)
+		for i, ref := range refs {
+			w.blockBreak(1)
+			w.writeRaw("[" + strconv.Itoa(i+1) + "]: " + ref)
+		}
+	}
+
+	return strings.Trim(w.b.String(), "\n")
+}
+
+// render writes a node and all of its children.
+func (w *markdownWriter) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.writeWords(n.Data, w.escape)
+		return
+	case html.DocumentNode:
+		w.renderChildren(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if htmlSkipElements[n.DataAtom] {
+		return
+	}
+
+	// Markdown needs an empty line between blocks, except for list items
+	breaks := 0
+	if htmlBlockElements[n.DataAtom] > 0 {
+		breaks = 2
+	}
+	switch n.DataAtom {
+	case atom.Li:
+		breaks = 1
+	case atom.Ul, atom.Ol:
+		if n.Parent != nil && n.Parent.DataAtom == atom.Li {
+			breaks = 1
+		}
+	}
+	w.blockBreak(breaks)
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.renderHeading(n)
+
+	case atom.Br:
+		w.hardBreak = "  "
+		w.breaks++
+		w.space = false
+
+	case atom.Hr:
+		w.writeRaw("---")
+
+	case atom.Em, atom.I:
+		// * works within words, unlike _
+		w.renderInline(n, "*")
+
+	case atom.Strong, atom.B:
+		w.renderInline(n, "**")
+
+	case atom.Del, atom.S, atom.Strike:
+		w.renderInline(n, "~~")
+
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		w.writeRaw(w.escapePipes(markdownCode(htmlTextContent(n))))
+
+	case atom.A:
+		w.renderLink(n)
+
+	case atom.Img:
+		w.renderImage(n)
+
+	case atom.Pre:
+		w.renderCodeBlock(n)
+
+	case atom.Blockquote:
+		w.pushPrefix("> ")
+		w.renderChildren(n)
+		w.popPrefix()
+
+	case atom.Ul, atom.Ol:
+		w.renderList(n)
+
+	case atom.Table:
+		w.renderTable(n)
+
+	default:
+		w.renderChildren(n)
+	}
+
+	w.blockBreak(breaks)
+}
+
+// renderChildren writes all children of a node.
+func (w *markdownWriter) renderChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.render(c)
+	}
+}
+
+// escape escapes a word of text.
+func (w *markdownWriter) escape(word string, lineStart bool) string {
+	word = w.escapePipes(markdownEscaper.Replace(word))
+
+	if lineStart {
+		// These only have a meaning at the start of a line
+		switch word[0] {
+		case '#', '>', '+', '-', '=':
+			word = `\` + word
+		}
+		word = reMarkdownOrderedListStart.ReplaceAllString(word, `$1\$2`)
+	}
+
+	return word
+}
+
+// escapePipes escapes the pipes in Markdown within a table cell.
+// GFM splits the cells at every pipe that isn't escaped, even in code and link destinations.
+func (w *markdownWriter) escapePipes(markdown string) string {
+	if w.inTable {
+		return strings.Replace(markdown, "|", `\|`, -1)
+	}
+	return markdown
+}
+
+// inline renders the children of a node as a single line of Markdown.
+// It also reports whether there was whitespace before and after the content.
+func (w *markdownWriter) inline(n *html.Node) (content string, before, after bool) {
+	iw := &markdownWriter{opts: w.opts, refs: w.refs, inTable: w.inTable}
+	iw.renderChildren(n)
+
+	content = iw.b.String()
+	before = strings.HasPrefix(content, " ")
+	after = iw.space
+	content = strings.TrimLeft(content, " ")
+
+	return
+}
+
+// renderInline writes the children of a node surrounded by delimiters, e.g. ** for bold.
+func (w *markdownWriter) renderInline(n *html.Node, delim string) {
+	content, before, after := w.inline(n)
+
+	// Delimiters can't span blocks, so just write the content
+	if strings.Contains(content, "\n") {
+		w.renderChildren(n)
+		return
+	}
+
+	if before {
+		w.space = true
+	}
+	if content != "" {
+		w.writeRaw(delim + content + delim)
+	}
+	if after {
+		w.space = true
+	}
+}
+
+// renderHeading writes a heading in the configured style.
+func (w *markdownWriter) renderHeading(n *html.Node) {
+	content, _, _ := w.inline(n)
+	content = strings.Join(strings.Fields(content), " ")
+	if content == "" {
+		return
+	}
+
+	level := int(n.Data[1] - '0')
+	if w.opts.HeadingStyle == HeadingSetext && level <= 2 {
+		underline := "="
+		if level == 2 {
+			underline = "-"
+		}
+		w.writeRaw(content)
+		w.blockBreak(1)
+		w.writeRaw(strings.Repeat(underline, utf8.RuneCountInString(content)))
+		return
+	}
+
+	w.writeRaw(strings.Repeat("#", level) + " " + content)
+}
+
+// renderLink writes a link in the configured style.
+func (w *markdownWriter) renderLink(n *html.Node) {
+	href := strings.TrimSpace(htmlAttr(n, "href"))
+	content, before, after := w.inline(n)
+	if href == "" || strings.Contains(content, "\n") {
+		w.renderChildren(n)
+		return
+	}
+
+	if before {
+		w.space = true
+	}
+
+	title := htmlAttr(n, "title")
+	switch {
+	case content == href && title == "" && strings.Contains(href, ":"):
+		w.writeRaw("<" + href + ">")
+	case w.opts.LinkStyle == LinkReference:
+		w.writeRaw("[" + content + "][" + w.reference(href, title) + "]")
+	default:
+		w.writeRaw("[" + content + "](" + w.escapePipes(markdownDestination(href, title)) + ")")
+	}
+
+	if after {
+		w.space = true
+	}
+}
+
+// renderImage writes an image in the configured style.
+func (w *markdownWriter) renderImage(n *html.Node) {
+	src := strings.TrimSpace(htmlAttr(n, "src"))
+	if src == "" {
+		return
+	}
+
+	alt := w.escapePipes(markdownEscaper.Replace(strings.Join(strings.Fields(htmlAttr(n, "alt")), " ")))
+	title := htmlAttr(n, "title")
+	if w.opts.LinkStyle == LinkReference {
+		w.writeRaw("![" + alt + "][" + w.reference(src, title) + "]")
+	} else {
+		w.writeRaw("![" + alt + "](" + w.escapePipes(markdownDestination(src, title)) + ")")
+	}
+}
+
+// reference adds a URL to the reference list and returns its label.
+// The same URL and title share a label.
+func (w *markdownWriter) reference(url, title string) string {
+	ref := markdownDestination(url, title)
+	for i, r := range *w.refs {
+		if r == ref {
+			return strconv.Itoa(i + 1)
+		}
+	}
+	*w.refs = append(*w.refs, ref)
+	return strconv.Itoa(len(*w.refs))
+}
+
+// renderCodeBlock writes preformatted text as a fenced code block.
+// The language is taken from a language-* class, as used by most syntax highlighters.
+func (w *markdownWriter) renderCodeBlock(n *html.Node) {
+	code := htmlTextContent(n)
+	code = strings.TrimSuffix(code, "\n")
+
+	lang := ""
+	for _, node := range []*html.Node{n, n.FirstChild} {
+		if node == nil || node.Type != html.ElementNode {
+			continue
+		}
+		if m := reMarkdownLanguage.FindStringSubmatch(htmlAttr(node, "class")); m != nil {
+			lang = m[1]
+			break
+		}
+	}
+
+	// The fence must be longer than any run of backticks in the code
+	fence := "```"
+	for _, run := range reBacktickRuns.FindAllString(code, -1) {
+		if len(run) >= len(fence) {
+			fence = strings.Repeat("`", len(run)+1)
+		}
+	}
+
+	lines := []string{fence + lang}
+	lines = append(lines, strings.Split(code, "\n")...)
+	lines = append(lines, fence)
+	w.writeLines(lines)
+}
+
+// renderList writes the items of an ordered or unordered list.
+func (w *markdownWriter) renderList(n *html.Node) {
+	num := 1
+	if start, err := strconv.Atoi(htmlAttr(n, "start")); err == nil {
+		num = start
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			w.render(c)
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+
+		w.startListItem(marker)
+		w.renderChildren(c)
+		w.endListItem()
+	}
+}
+
+// renderTable writes a table as a GFM table.
+// The first row is used as the header, as GFM tables always have one.
+// Tables with more than maxTableColumns columns are written as a paragraph per row instead.
+func (w *markdownWriter) renderTable(n *html.Node) {
+	cols := htmlTableColumns(n)
+	if cols > maxTableColumns {
+		w.renderTableRows(n)
+		return
+	}
+
+	var rows [][]string
+	var aligns []string
+	w.collectTableRows(n, &rows, &aligns)
+	if len(rows) == 0 {
+		return
+	}
+
+	var lines []string
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+
+		if i == 0 {
+			sep := make([]string, cols)
+			for j := range sep {
+				sep[j] = "---"
+				if j < len(aligns) {
+					switch aligns[j] {
+					case "left":
+						sep[j] = ":---"
+					case "center":
+						sep[j] = ":---:"
+					case "right":
+						sep[j] = "---:"
+					}
+				}
+			}
+			lines = append(lines, "| "+strings.Join(sep, " | ")+" |")
+		}
+	}
+
+	w.writeLines(lines)
+}
+
+// renderTableRows writes each row of a table as a paragraph with the content of its cells.
+func (w *markdownWriter) renderTableRows(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			w.renderTableRows(c)
+		case atom.Tr:
+			w.blockBreak(2)
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					w.renderChildren(cell)
+					w.space = true
+				}
+			}
+			w.blockBreak(2)
+		}
+	}
+}
+
+// collectTableRows renders the cells of all rows in a table.
+// Cells spanning several columns are followed by empty cells.
+// The alignment of the columns is taken from the first row.
+func (w *markdownWriter) collectTableRows(n *html.Node, rows *[][]string, aligns *[]string) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			w.collectTableRows(c, rows, aligns)
+			continue
+		case atom.Tr:
+		default:
+			continue
+		}
+
+		var row []string
+		for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+				continue
+			}
+
+			cw := &markdownWriter{opts: w.opts, refs: w.refs, inTable: true}
+			cw.renderChildren(cell)
+			content := strings.Join(strings.Fields(strings.Replace(cw.b.String(), "\n", " ", -1)), " ")
+
+			span := tableColSpan(cell)
+			row = append(row, content)
+			if len(*rows) == 0 {
+				*aligns = append(*aligns, strings.ToLower(htmlAttr(cell, "align")))
+			}
+			for i := 1; i < span; i++ {
+				row = append(row, "")
+				if len(*rows) == 0 {
+					*aligns = append(*aligns, "")
+				}
+			}
+		}
+		*rows = append(*rows, row)
+	}
+}
+
+// markdownCode returns inline code, with enough backticks to contain any backticks in the code.
+func markdownCode(code string) string {
+	code = strings.Join(strings.FieldsFunc(code, func(r rune) bool {
+		return strings.ContainsRune(htmlSpace, r)
+	}), " ")
+	if code == "" {
+		return ""
+	}
+
+	fence := "`"
+	for _, run := range reBacktickRuns.FindAllString(code, -1) {
+		if len(run) >= len(fence) {
+			fence = strings.Repeat("`", len(run)+1)
+		}
+	}
+
+	// Code starting or ending with a backtick needs a space to separate it from the fence
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// markdownDestination returns a link destination with an optional title.
+// URLs with spaces or parentheses are put in angle brackets.
+func markdownDestination(url, title string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		url = "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	if title != "" {
+		url += ` "` + strings.Replace(title, `"`, `\"`, -1) + `"`
+	}
+	return url
+}
@@ -0,0 +1,97 @@
+package texttools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	samples := []sample{
+		{"<h1>Title</h1><h3>Third</h3><p>Text</p>", "# Title\n\n### Third\n\nText"},
+		{"<p>Some <b>bold</b>, <em> italic </em> and <del>gone</del> text</p>", "Some **bold**, *italic* and ~~gone~~ text"},
+		{"<p><strong><i>both</i></strong></p>", "***both***"},
+		{"<p>foo<em>bar</em>baz</p>", "foo*bar*baz"},
+		{"<p>Use <code>go test</code> or <code>a`b</code></p>", "Use `go test` or ``a`b``"},
+		{`<a href="https://example.com" title="Example">a link</a>`, `[a link](https://example.com "Example")`},
+		{`<a href="https://example.com">https://example.com</a>`, "<https://example.com>"},
+		{`<a href="/with space">link</a>`, "[link](</with space>)"},
+		{`<img src="cat.png" alt="A *cat*">`, `![A \*cat\*](cat.png)`},
+		{"<ul><li>one</li><li>two<ol><li>a</li><li>b</li></ol></li></ul>", "- one\n- two\n  1. a\n  2. b"},
+		{"<ol start=3><li>three</li></ol>", "3. three"},
+		{"<blockquote><p>q1</p><p>q2<br>line</p></blockquote>", "> q1\n>\n> q2  \n> line"},
+		{"<pre><code class=\"language-go\">fmt.Println()\n</code></pre>", "```go\nfmt.Println()\n```"},
+		{"<pre>has ``` fence</pre>", "````\nhas ``` fence\n````"},
+		{"<p>a</p><hr><p>b</p>", "a\n\n---\n\nb"},
+		{"<div>a</div><div>b</div>", "a\n\nb"},
+		{"<p>1. not a list, * not _emphasis_ [or link]</p>", `1\. not a list, \* not \_emphasis\_ \[or link\]`},
+		{"<p># not a heading</p><p>- not an item</p>", `\# not a heading` + "\n\n" + `\- not an item`},
+		{"<script>alert(1)</script>Safe &amp; sound", "Safe &amp; sound"},
+		{"<p>&amp;copy; is not &copy;</p>", "&amp;copy; is not ©"},
+		{"<p>~~not struck~~</p>", `\~\~not struck\~\~`},
+	}
+
+	for _, sample := range samples {
+		if out := HTMLToMarkdown(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+
+	// Escaped text is the same text again when the Markdown is rendered
+	in := "<p>~~not struck~~ and *not bold*</p>"
+	if out := MarkdownToText(HTMLToMarkdown(in)); out != "~~not struck~~ and *not bold*" {
+		t.Errorf("got %q from %q, expected %q", out, in, "~~not struck~~ and *not bold*")
+	}
+}
+
+func TestHTMLToMarkdownTable(t *testing.T) {
+	in := "<table><thead><tr><th align=left>Item</th><th align=right>Price</th></tr></thead>" +
+		"<tbody><tr><td>A|B</td><td>$1</td></tr><tr><td><code>x|y</code></td><td><img src=\"p.png\" alt=\"p|q\"></td></tr>" +
+		"<tr><td colspan=2>Total</td></tr></tbody></table>"
+	expected := "| Item | Price |\n| :--- | ---: |\n| A\\|B | $1 |\n| `x\\|y` | ![p\\|q](p.png) |\n| Total |  |"
+
+	if out := HTMLToMarkdown(in); out != expected {
+		t.Errorf("got %q from %q, expected %q", out, in, expected)
+	}
+}
+
+func TestHTMLToMarkdownTableHugeColSpan(t *testing.T) {
+	// Tables with more than 100 columns are written as a paragraph per row
+	in := "<table><tr><td colspan=5000000>x</td><td># y</td></tr><tr><td>a</td></tr></table>"
+	if out := HTMLToMarkdown(in); out != "x # y\n\na" {
+		t.Errorf("got %q from %q, expected %q", out, in, "x # y\n\na")
+	}
+
+	// Padding every row to the full width would take up gigabytes
+	row := "<tr>" + strings.Repeat("<td colspan=1000>x", 100)
+	in = "<table>" + strings.Repeat(row, 100) + "</table>"
+	if out := HTMLToMarkdown(in); len(out) > len(in) {
+		t.Errorf("got %d chars from %d chars of HTML, expected at most %d", len(out), len(in), len(in))
+	}
+
+	// Tables up to the limit are still GFM tables
+	in = "<table><tr><td colspan=100>x</td></tr></table>"
+	expected := "| x |" + strings.Repeat("  |", 99) + "\n|" + strings.Repeat(" --- |", 100)
+	if out := HTMLToMarkdown(in); out != expected {
+		t.Errorf("got %q from %q, expected %q", out, in, expected)
+	}
+}
+
+func TestHTMLToMarkdownWithOptions(t *testing.T) {
+	in := `<h1>Title</h1><h2>Sub</h2><h3>Third</h3>` +
+		`<p><a href="https://example.com">one</a>, <a href="https://example.com">two</a> and <img src="cat.png" alt="cat"></p>`
+	expected := "Title\n=====\n\nSub\n---\n\n### Third\n\n" +
+		"[one][1], [two][1] and ![cat][2]\n\n" +
+		"[1]: https://example.com\n" +
+		"[2]: cat.png"
+
+	opts := MarkdownOptions{HeadingStyle: HeadingSetext, LinkStyle: LinkReference}
+	if out := HTMLToMarkdownWithOptions(in, opts); out != expected {
+		t.Errorf("got %q from %q, expected %q", out, in, expected)
+	}
+}
+
+func BenchmarkHTMLToMarkdown(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = HTMLToMarkdown(`<h1>Title</h1><p>Some <b>sample</b> text with <a href="https://example.com">a link</a></p><ul><li>one</li><li>two</li></ul>`)
+	}
+}
@@ -0,0 +1,365 @@
+package texttools
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLPolicy is an allowlist of the HTML kept by Sanitize.
+// Anything not allowed is removed, but the text content of removed elements is kept,
+// except for elements like script and style, which are removed entirely.
+type HTMLPolicy struct {
+	// Elements maps the allowed elements to the attributes allowed on them.
+	Elements map[string][]string
+
+	// GlobalAttributes are allowed on all allowed elements.
+	GlobalAttributes []string
+
+	// URLSchemes are the allowed schemes for URL attributes, like href and src.
+	// Relative URLs are always allowed.
+	URLSchemes []string
+
+	// LinkRel is set as the rel attribute of all links with an href, e.g. "nofollow noopener".
+	// Empty means that rel is treated like any other attribute.
+	LinkRel string
+}
+
+// Elements whose content is removed together with the element, unless the element is allowed.
+var htmlDropElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"frame":    true,
+	"frameset": true,
+	"object":   true,
+	"embed":    true,
+	"applet":   true,
+	"template": true,
+	"noscript": true,
+	"noembed":  true,
+	"noframes": true,
+	"xmp":      true,
+	"title":    true,
+	"head":     true,
+	"textarea": true,
+	"select":   true,
+	"svg":      true,
+	"math":     true,
+}
+
+// Elements that start foreign content, where self closing tags like <svg/> are empty elements.
+var htmlForeignElements = map[string]bool{
+	"svg":  true,
+	"math": true,
+}
+
+// Elements that never have an end tag.
+var htmlVoidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// Elements that are implicitly closed by the start of another element, like an li by the next li.
+var htmlImpliedEnds = map[string][]string{
+	"li": {"li"},
+	"dt": {"dt", "dd"},
+	"dd": {"dt", "dd"},
+	"p":  {"p"},
+	"tr": {"tr", "td", "th"},
+	"td": {"td", "th"},
+	"th": {"td", "th"},
+}
+
+// Attributes that contain URLs.
+var htmlURLAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"cite":       true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"background": true,
+	"longdesc":   true,
+	"srcset":     true,
+	"xlink:href": true,
+}
+
+var (
+	reCSSComment   = regexp.MustCompile(`/\*.*?\*/`)
+	reCSSEscape    = regexp.MustCompile(`\\([0-9a-fA-F]{1,6})\s?`)
+	reCSSDangerous = regexp.MustCompile(`(?i)expression\s*\(|javascript:|vbscript:|url\s*\(|behavior\s*:|-moz-binding|@import|\\`)
+	reURLIgnored   = regexp.MustCompile(`[\x00-\x20\x7f]+`)
+)
+
+// StrictHTMLPolicy returns a policy that only allows basic text formatting, lists, quotes, code and links.
+// Links can only use http, https and mailto, and get rel="nofollow noopener".
+func StrictHTMLPolicy() *HTMLPolicy {
+	return &HTMLPolicy{
+		Elements: map[string][]string{
+			"a":          {"href", "title"},
+			"b":          nil,
+			"strong":     nil,
+			"i":          nil,
+			"em":         nil,
+			"u":          nil,
+			"s":          nil,
+			"del":        nil,
+			"sub":        nil,
+			"sup":        nil,
+			"p":          nil,
+			"br":         nil,
+			"ul":         nil,
+			"ol":         nil,
+			"li":         nil,
+			"blockquote": nil,
+			"code":       nil,
+			"pre":        nil,
+		},
+		URLSchemes: []string{"http", "https", "mailto"},
+		LinkRel:    "nofollow noopener",
+	}
+}
+
+// UGCHTMLPolicy returns a policy for user generated content, like StrictHTMLPolicy,
+// but which also allows headings, images, tables and a few more elements.
+func UGCHTMLPolicy() *HTMLPolicy {
+	p := StrictHTMLPolicy()
+	for _, el := range []string{"h1", "h2", "h3", "h4", "h5", "h6", "hr", "span", "div", "dl", "dt", "dd", "abbr", "mark", "small", "kbd", "caption", "thead", "tbody", "tfoot", "tr"} {
+		p.Elements[el] = nil
+	}
+	p.Elements["img"] = []string{"src", "alt", "title", "width", "height"}
+	p.Elements["table"] = []string{"summary"}
+	p.Elements["td"] = []string{"colspan", "rowspan", "align"}
+	p.Elements["th"] = []string{"colspan", "rowspan", "align", "scope"}
+	p.Elements["ol"] = []string{"start"}
+	p.Elements["q"] = []string{"cite"}
+	p.Elements["blockquote"] = []string{"cite"}
+	p.GlobalAttributes = []string{"title", "lang", "dir"}
+	return p
+}
+
+// SanitizeHTML removes everything but basic formatting from HTML, using StrictHTMLPolicy.
+// It's meant for showing user generated HTML, like comments, without the risk of XSS.
+func SanitizeHTML(str string) string {
+	return StrictHTMLPolicy().Sanitize(str)
+}
+
+// Sanitize removes all elements and attributes not allowed by the policy from the HTML.
+// Event handlers, comments and URLs with schemes that are not allowed, like javascript:, are always removed,
+// and style attributes are removed if they contain anything that can run scripts or load resources.
+// All open elements are closed at the end.
+func (p *HTMLPolicy) Sanitize(str string) string {
+	var b strings.Builder
+	var open []string
+	dropping := ""
+	dropDepth := 0
+
+	z := html.NewTokenizer(strings.NewReader(str))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		name := strings.ToLower(tok.Data)
+
+		// Skip everything inside elements that are removed with their content
+		if dropping != "" {
+			switch {
+			case (tt == html.StartTagToken || tt == html.SelfClosingTagToken && !htmlForeignElements[name]) && name == dropping:
+				dropDepth++
+			case tt == html.EndTagToken && name == dropping:
+				dropDepth--
+				if dropDepth == 0 {
+					dropping = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(tok.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if _, ok := p.Elements[name]; !ok {
+				// Browsers ignore the slash of self closing tags like <script/>, except for void and foreign elements
+				selfClosing := tt == html.SelfClosingTagToken && htmlForeignElements[name]
+				if htmlDropElements[name] && !htmlVoidElements[name] && !selfClosing {
+					dropping = name
+					dropDepth = 1
+				}
+				continue
+			}
+
+			// Close the elements that this element implicitly ends
+			for len(open) > 0 && StringInSlice(open[len(open)-1], htmlImpliedEnds[name]) {
+				b.WriteString("</" + open[len(open)-1] + ">")
+				open = open[:len(open)-1]
+			}
+
+			b.WriteString("<" + name + p.attributes(name, tok.Attr) + ">")
+			if !htmlVoidElements[name] {
+				if tt == html.SelfClosingTagToken {
+					b.WriteString("</" + name + ">")
+				} else {
+					open = append(open, name)
+				}
+			}
+
+		case html.EndTagToken:
+			// Only close elements that are open, and close any elements opened inside them
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+
+	return b.String()
+}
+
+// attributes returns the allowed attributes of an element, ready to be written in a tag.
+func (p *HTMLPolicy) attributes(name string, attrs []html.Attribute) string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	isLink := false
+
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" {
+			key = attr.Namespace + ":" + key
+		}
+		if seen[key] || !p.allowsAttribute(name, key) {
+			continue
+		}
+
+		val := attr.Val
+		switch {
+		case strings.HasPrefix(key, "on"):
+			// Event handlers are never allowed, even if they are in the policy
+			continue
+		case key == "rel" && p.LinkRel != "" && name == "a":
+			continue
+		case key == "style":
+			if !safeCSS(val) {
+				continue
+			}
+		case key == "srcset":
+			if !p.allowsSrcset(val) {
+				continue
+			}
+		case htmlURLAttributes[key]:
+			var ok bool
+			if val, ok = p.cleanURL(val); !ok {
+				continue
+			}
+		}
+
+		if name == "a" && key == "href" {
+			isLink = true
+		}
+		seen[key] = true
+		b.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+
+	if isLink && p.LinkRel != "" {
+		b.WriteString(` rel="` + html.EscapeString(p.LinkRel) + `"`)
+	}
+
+	return b.String()
+}
+
+// allowsAttribute reports whether the policy allows an attribute on an element.
+func (p *HTMLPolicy) allowsAttribute(name, key string) bool {
+	for _, a := range p.Elements[name] {
+		if strings.ToLower(a) == key {
+			return true
+		}
+	}
+	for _, a := range p.GlobalAttributes {
+		if strings.ToLower(a) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanURL removes the chars browsers ignore in URLs, and reports whether the URL has an allowed scheme.
+func (p *HTMLPolicy) cleanURL(val string) (string, bool) {
+	val = strings.TrimSpace(val)
+
+	// Browsers ignore tabs and newlines anywhere in a URL, so "java\tscript:" is still javascript:
+	u, err := url.Parse(reURLIgnored.ReplaceAllString(val, ""))
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme == "" {
+		// A colon before any slash, question mark or hash would make it a scheme in a browser
+		if i := strings.IndexAny(val, ":/?#"); i >= 0 && val[i] == ':' {
+			return "", false
+		}
+		return val, true
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	for _, s := range p.URLSchemes {
+		if strings.ToLower(s) == scheme {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// allowsSrcset reports whether all URLs in a srcset attribute are allowed.
+func (p *HTMLPolicy) allowsSrcset(val string) bool {
+	for _, candidate := range strings.Split(val, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, ok := p.cleanURL(fields[0]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// safeCSS reports whether inline CSS is free of anything that can run scripts or load resources.
+// CSS escapes and comments are resolved first, as they can be used to hide e.g. "expression(".
+func safeCSS(css string) bool {
+	css = reCSSComment.ReplaceAllString(css, "")
+	css = reCSSEscape.ReplaceAllStringFunc(css, func(m string) string {
+		n, err := strconv.ParseUint(strings.TrimSpace(m[1:]), 16, 32)
+		if err != nil || n == 0 || n > 0x10FFFF {
+			return "�"
+		}
+		return string(rune(n))
+	})
+	css = reURLIgnored.ReplaceAllString(css, "")
+	return !reCSSDangerous.MatchString(css) && !strings.Contains(css, "/*")
+}
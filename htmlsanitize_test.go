@@ -0,0 +1,215 @@
+package texttools
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// XSS vectors, mostly from the OWASP XSS filter evasion cheat sheet.
+var xssSamples = []string{
+	`<script>alert('XSS')</script>`,
+	`<SCRIPT SRC=http://xss.rocks/xss.js></SCRIPT>`,
+	`<script/xss src="http://xss.rocks/xss.js"></script>`,
+	`<<SCRIPT>alert("XSS");//<</SCRIPT>`,
+	`<SCRIPT SRC=http://xss.rocks/xss.js?< B >`,
+	`<scr<script>ipt>alert(1)</scr</script>ipt>`,
+	`<IMG SRC="javascript:alert('XSS');">`,
+	`<IMG SRC=javascript:alert('XSS')>`,
+	`<IMG SRC=JaVaScRiPt:alert('XSS')>`,
+	"<IMG SRC=`javascript:alert(\"RSnake says, 'XSS'\")`>",
+	`<IMG """><SCRIPT>alert("XSS")</SCRIPT>"\>`,
+	`<IMG SRC=javascript:alert(String.fromCharCode(88,83,83))>`,
+	`<IMG SRC=# onmouseover="alert('xxs')">`,
+	`<IMG SRC= onmouseover="alert('xxs')">`,
+	`<IMG onmouseover="alert('xxs')">`,
+	`<IMG SRC=/ onerror="alert(String.fromCharCode(88,83,83))"></img>`,
+	`<img src=x onerror="&#0000106&#0000097&#0000118&#0000097&#0000115&#0000099&#0000114&#0000105&#0000112&#0000116&#0000058&#0000097&#0000108&#0000101&#0000114&#0000116&#0000040&#0000039&#0000088&#0000083&#0000083&#0000039&#0000041">`,
+	`<IMG SRC=&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;&#97;&#108;&#101;&#114;&#116;&#40;&#39;&#88;&#83;&#83;&#39;&#41;>`,
+	`<IMG SRC=&#0000106&#0000097&#0000118&#0000097&#0000115&#0000099&#0000114&#0000105&#0000112&#0000116&#0000058&#0000097&#0000108&#0000101&#0000114&#0000116&#0000040&#0000039&#0000088&#0000083&#0000083&#0000039&#0000041>`,
+	`<IMG SRC=&#x6A&#x61&#x76&#x61&#x73&#x63&#x72&#x69&#x70&#x74&#x3A&#x61&#x6C&#x65&#x72&#x74&#x28&#x27&#x58&#x53&#x53&#x27&#x29>`,
+	`<IMG SRC="jav	ascript:alert('XSS');">`,
+	`<IMG SRC="jav&#x09;ascript:alert('XSS');">`,
+	`<IMG SRC="jav&#x0A;ascript:alert('XSS');">`,
+	`<IMG SRC="jav&#x0D;ascript:alert('XSS');">`,
+	"<IMG SRC=\"jav\x00ascript:alert('XSS');\">",
+	`<IMG SRC=" &#14;  javascript:alert('XSS');">`,
+	`<BODY onload!#$%&()*~+-_.,:;?@[/|\]^` + "`" + `=alert("XSS")>`,
+	`<IFRAME SRC="javascript:alert('XSS');"></IFRAME>`,
+	`<iframe src=http://xss.rocks/scriptlet.html <`,
+	`<FRAMESET><FRAME SRC="javascript:alert('XSS');"></FRAMESET>`,
+	`<TABLE BACKGROUND="javascript:alert('XSS')">`,
+	`<TABLE><TD BACKGROUND="javascript:alert('XSS')">`,
+	`<DIV STYLE="background-image: url(javascript:alert('XSS'))">`,
+	`<DIV STYLE="background-image:\0075\0072\006C\0028'\006a\0061\0076\0061\0073\0063\0072\0069\0070\0074\003a\0061\006c\0065\0072\0074\0028.1027\0058.1053\0053\0027\0029'\0029">`,
+	`<DIV STYLE="width: expression(alert('XSS'));">`,
+	`<IMG STYLE="xss:expr/*XSS*/ession(alert('XSS'))">`,
+	`<XSS STYLE="behavior: url(xss.htc);">`,
+	`<STYLE>@import'http://xss.rocks/xss.css';</STYLE>`,
+	`<STYLE>BODY{-moz-binding:url("http://xss.rocks/xssmoz.xml#xss")}</STYLE>`,
+	`<LINK REL="stylesheet" HREF="javascript:alert('XSS');">`,
+	`<META HTTP-EQUIV="refresh" CONTENT="0;url=javascript:alert('XSS');">`,
+	`<META HTTP-EQUIV="refresh" CONTENT="0;url=data:text/html base64,PHNjcmlwdD5hbGVydCgnWFNTJyk8L3NjcmlwdD4K">`,
+	`<OBJECT TYPE="text/x-scriptlet" DATA="http://xss.rocks/scriptlet.html"></OBJECT>`,
+	`<EMBED SRC="data:image/svg+xml;base64,PHN2ZyB4bWxuczpzdmc9Imh0dH A6Ly93d3cudzMub3JnLzIwMDAvc3ZnIiB4bWxucz0iaHR0cDovL3d3dy53My5vcmcv MjAwMC9zdmciIHhtbG5zOnhsaW5rPSJodHRwOi8vd3d3LnczLm9yZy8xOTk5L3hs aW5rIiB2ZXJzaW9uPSIxLjAiIHg9IjAiIHk9IjAiIHdpZHRoPSIxOTQiIGhlaWdodD0iMjAw IiBpZD0ieHNzIj48c2NyaXB0IHR5cGU9InRleHQvZWNtYXNjcmlwdCI+YWxlcnQoIlh TUyIpOzwvc2NyaXB0Pjwvc3ZnPg==" type="image/svg+xml" AllowScriptAccess="always"></EMBED>`,
+	`<svg/onload=alert('XSS')>`,
+	`<svg><script>alert(1)</script></svg>`,
+	`<math><mi xlink:href="javascript:alert(1)">click</mi></math>`,
+	`<a href="javascript:alert(1)">click</a>`,
+	`<a href="JaVaScRiPt:alert(1)">click</a>`,
+	`<a href="  javascript:alert(1)">click</a>`,
+	`<a href="java&#x09;script:alert(1)">click</a>`,
+	`<a href="&#106;avascript:alert(1)">click</a>`,
+	`<a href="vbscript:msgbox(1)">click</a>`,
+	`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">click</a>`,
+	`<a href="javascript&colon;alert(1)">click</a>`,
+	`<a href="jav&#97;script:alert(1)" onclick="alert(1)" onmouseover=alert(1)>click</a>`,
+	`<a href=x onclick=alert(1)//>click</a>`,
+	`<a/href="javascript:alert(1)">click</a>`,
+	`<b onmouseover=alert('Wufff!')>click me!</b>`,
+	`<p style="x:expression(alert(1))">text</p>`,
+	`<BGSOUND SRC="javascript:alert('XSS');">`,
+	`<BR SIZE="&{alert('XSS')}">`,
+	`<INPUT TYPE="IMAGE" SRC="javascript:alert('XSS');">`,
+	`<BODY BACKGROUND="javascript:alert('XSS')">`,
+	`<BODY ONLOAD=alert('XSS')>`,
+	`<BASE HREF="javascript:alert('XSS');//">`,
+	`<form action="javascript:alert(1)"><button formaction="javascript:alert(1)">x</button></form>`,
+	`<!--[if gte IE 4]><SCRIPT>alert('XSS');</SCRIPT><![endif]-->`,
+	`<!-- <script>alert(1)</script> -->`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+	`<textarea><script>alert(1)</script></textarea>`,
+	`<title><script>alert(1)</script></title>`,
+	`<xmp><script>alert(1)</script></xmp>`,
+	`<template><script>alert(1)</script></template>`,
+	`<details open ontoggle=alert(1)>`,
+	`<video><source onerror="alert(1)">`,
+	`<audio src=x onerror=alert(1)>`,
+	`<marquee onstart=alert(1)>`,
+	`<isindex action=javascript:alert(1) type=image>`,
+	`"><script>alert(1)</script>`,
+	`'><script>alert(1)</script>`,
+	`</p><script>alert(1)</script><p>`,
+	`<p>unclosed <b>bold <script>alert(1)`,
+	`<plaintext><script>alert(1)</script>`,
+	`<script/>alert(1)</script>`,
+}
+
+// checkSanitized parses sanitized HTML and reports anything the policy should have removed.
+func checkSanitized(t *testing.T, p *HTMLPolicy, in, out string) {
+	doc, err := html.Parse(strings.NewReader(out))
+	if err != nil {
+		t.Errorf("got unparsable %q from %q: %v", out, in, err)
+		return
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.CommentNode {
+			t.Errorf("got comment in %q from %q", out, in)
+		}
+		if n.Type == html.ElementNode && n.Parent != nil && n.Parent.Type != html.DocumentNode && n.Data != "head" && n.Data != "body" {
+			if _, ok := p.Elements[n.Data]; !ok {
+				t.Errorf("got element %q in %q from %q", n.Data, out, in)
+			}
+			for _, attr := range n.Attr {
+				key := strings.ToLower(attr.Key)
+				val := strings.ToLower(reURLIgnored.ReplaceAllString(attr.Val, ""))
+				if strings.HasPrefix(key, "on") {
+					t.Errorf("got event handler %q in %q from %q", key, out, in)
+				}
+				if strings.Contains(val, "javascript:") || strings.Contains(val, "vbscript:") || strings.Contains(val, "data:") || strings.Contains(val, "expression(") {
+					t.Errorf("got dangerous %s=%q in %q from %q", key, attr.Val, out, in)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if strings.Contains(strings.ToLower(out), "<script") {
+		t.Errorf("got script in %q from %q", out, in)
+	}
+}
+
+func TestSanitizeHTMLXSS(t *testing.T) {
+	policies := []*HTMLPolicy{StrictHTMLPolicy(), UGCHTMLPolicy()}
+
+	// Also allow style, to test the CSS checks
+	withStyle := UGCHTMLPolicy()
+	withStyle.GlobalAttributes = append(withStyle.GlobalAttributes, "style", "background")
+	policies = append(policies, withStyle)
+
+	for _, p := range policies {
+		for _, in := range xssSamples {
+			checkSanitized(t, p, in, p.Sanitize(in))
+		}
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	samples := []sample{
+		{"<b>bold</b> and <i>italic</i>", "<b>bold</b> and <i>italic</i>"},
+		{`<a href="https://example.com" target="_blank" rel="opener">link</a>`, `<a href="https://example.com" rel="nofollow noopener">link</a>`},
+		{`<a href="/relative?a=1&amp;b=2">link</a>`, `<a href="/relative?a=1&amp;b=2" rel="nofollow noopener">link</a>`},
+		{`<a href="javascript:alert(1)">link</a>`, "<a>link</a>"},
+		{`<a href="mailto:info@example.com">mail</a>`, `<a href="mailto:info@example.com" rel="nofollow noopener">mail</a>`},
+		{"<ul><li>one<li>two</ul>", "<ul><li>one</li><li>two</li></ul>"},
+		{"<p>unclosed <b>bold", "<p>unclosed <b>bold</b></p>"},
+		{"<b>mis<i>nested</b>text</i>", "<b>mis<i>nested</i></b>text"},
+		{"<p>one<p>two", "<p>one</p><p>two</p>"},
+		{"<div onclick=alert(1)>text</div>", "text"},
+		{"<script>alert(1)</script>safe", "safe"},
+		{"<style>p { color: red }</style>safe", "safe"},
+		{"<script/>alert(1)</script><p>hi</p>", "<p>hi</p>"},
+		{"<style/>p { color: red }</style>safe", "safe"},
+		{"a<svg/>b", "ab"},
+		{"a<math/>b<svg><svg/><script>alert(1)</script></svg>c", "abc"},
+		{"<!-- comment -->safe", "safe"},
+		{"5 < 6 & 7 > 3", "5 &lt; 6 &amp; 7 &gt; 3"},
+		{"<br/>", "<br>"},
+		{`<img src="cat.png">`, ""},
+	}
+
+	for _, sample := range samples {
+		if out := SanitizeHTML(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestHTMLPolicySanitize(t *testing.T) {
+	p := &HTMLPolicy{
+		Elements: map[string][]string{
+			"img":  {"src", "alt", "onerror"},
+			"span": {"style"},
+		},
+		URLSchemes: []string{"https", "data"},
+	}
+
+	samples := []sample{
+		{`<img src="https://example.com/cat.png" alt="cat" onerror="alert(1)">`, `<img src="https://example.com/cat.png" alt="cat">`},
+		{`<img src="http://example.com/cat.png">`, `<img>`},
+		{`<img src="data:image/png;base64,AAAA">`, `<img src="data:image/png;base64,AAAA">`},
+		{`<span style="color: red">red</span>`, `<span style="color: red">red</span>`},
+		{`<span style="color: expression(alert(1))">red</span>`, `<span>red</span>`},
+		{`<span style="background: url(https://example.com/track.png)">red</span>`, `<span>red</span>`},
+		{`<span style="x: e\78 pression(alert(1))">red</span>`, `<span>red</span>`},
+		{`<b>bold</b>`, "bold"},
+	}
+
+	for _, sample := range samples {
+		if out := p.Sanitize(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func BenchmarkSanitizeHTML(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = SanitizeHTML(`<p>Some <b>sample</b> text with <a href="https://example.com" onclick="alert(1)">a link</a></p><script>alert(1)</script>`)
+	}
+}
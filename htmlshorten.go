@@ -0,0 +1,194 @@
+package texttools
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlToken is a token from the HTML tokenizer, together with its original markup.
+type htmlToken struct {
+	tt  html.TokenType
+	tok html.Token
+	raw string
+}
+
+// visibleRune is a char of the visible text in HTML, and where it ends in the tokens.
+type visibleRune struct {
+	r     rune
+	token int
+	end   int
+}
+
+// ShortenHTML shortens HTML like Shorten does with text, but only counts the visible text.
+// It cuts at word boundaries, never inside a tag or an entity, and closes all open tags.
+// appendStr is text, not HTML, and is added inside the last open block element, e.g. before the closing </p>.
+// Length is counted in chars, with entities counted as the char they represent.
+// HTML that is already short enough is returned unchanged.
+func ShortenHTML(str string, length int, appendStr string) string {
+	tokens, visible, words := visibleHTMLWords(str)
+
+	// If the text is short enough, then return it
+	total := len(words) - 1
+	for _, word := range words {
+		total += word[1] - word[0]
+	}
+	if total <= length {
+		return str
+	}
+
+	appendLen := utf8.RuneCountInString(appendStr)
+
+	// Use as many words as possible
+	keep := 0
+	shorter := 0
+	for _, word := range words {
+		wordLen := word[1] - word[0]
+		if shorter+wordLen+appendLen >= length {
+			break
+		}
+		if shorter > 0 {
+			shorter++
+		}
+		shorter += wordLen
+		keep = word[1]
+	}
+
+	// If no words fit (e.g. due to 1 long word), cut the first word
+	if shorter == 0 {
+		keep = length - appendLen
+		if keep < 0 {
+			keep = 0
+		}
+	}
+
+	// If the appendStr not is empty, remove any typical punctuation
+	if keep > 0 && appendLen > 0 {
+		switch visible[keep-1].r {
+		case '.', ',', ';':
+			keep--
+		case '?', '!':
+			_, size := utf8.DecodeLastRuneInString(appendStr)
+			appendStr = appendStr[:len(appendStr)-size]
+		}
+	}
+
+	if keep == 0 {
+		return html.EscapeString(appendStr)
+	}
+
+	cut := visible[keep-1]
+	var b strings.Builder
+	var open []string
+
+	for i, t := range tokens[:cut.token+1] {
+		if i == cut.token {
+			b.WriteString(html.EscapeString(t.tok.Data[:cut.end]))
+			break
+		}
+		b.WriteString(t.raw)
+
+		name := t.tok.Data
+		switch t.tt {
+		case html.StartTagToken:
+			if htmlVoidElements[name] {
+				continue
+			}
+			for len(open) > 0 && StringInSlice(open[len(open)-1], htmlImpliedEnds[name]) {
+				open = open[:len(open)-1]
+			}
+			open = append(open, name)
+
+		case html.EndTagToken:
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j] == name {
+					open = open[:j]
+					break
+				}
+			}
+		}
+	}
+
+	// Close the inline elements, add the appendStr to the last open block element, and close the rest
+	appended := false
+	for i := len(open) - 1; i >= 0; i-- {
+		if !appended && isHTMLBlock(atom.Lookup([]byte(open[i]))) {
+			b.WriteString(html.EscapeString(appendStr))
+			appended = true
+		}
+		b.WriteString("</" + open[i] + ">")
+	}
+	if !appended {
+		b.WriteString(html.EscapeString(appendStr))
+	}
+
+	return b.String()
+}
+
+// visibleHTMLWords tokenizes HTML and finds the chars of the visible text.
+// Words are returned as [start, end) ranges of the visible chars.
+func visibleHTMLWords(str string) (tokens []htmlToken, visible []visibleRune, words [][2]int) {
+	skip := 0
+	inWord := false
+	endWord := func() {
+		if inWord {
+			words[len(words)-1][1] = len(visible)
+			inWord = false
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(str))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		t := htmlToken{tt: tt, raw: string(z.Raw()), tok: z.Token()}
+		tokens = append(tokens, t)
+		a := t.tok.DataAtom
+
+		switch tt {
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			for i, r := range t.tok.Data {
+				if strings.ContainsRune(htmlSpace, r) {
+					endWord()
+					continue
+				}
+				if !inWord {
+					words = append(words, [2]int{len(visible), len(visible)})
+					inWord = true
+				}
+				visible = append(visible, visibleRune{r: r, token: len(tokens) - 1, end: i + utf8.RuneLen(r)})
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if htmlSkipElements[a] && tt == html.StartTagToken {
+				skip++
+			}
+			if isHTMLBlock(a) || a == atom.Br || a == atom.Img {
+				endWord()
+			}
+
+		case html.EndTagToken:
+			if htmlSkipElements[a] && skip > 0 {
+				skip--
+			}
+			if isHTMLBlock(a) {
+				endWord()
+			}
+		}
+	}
+	endWord()
+
+	return
+}
+
+// isHTMLBlock reports whether an element is a block element or a table cell.
+func isHTMLBlock(a atom.Atom) bool {
+	return htmlBlockElements[a] > 0 || a == atom.Td || a == atom.Th || a == atom.Caption
+}
@@ -0,0 +1,51 @@
+package texttools
+
+import "testing"
+
+func TestShortenHTML(t *testing.T) {
+	samples := []sample{
+		{`<p>Short <b>text</b></p>`, `<p>Short <b>text</b></p>`},
+		{`<p>sample text that is too long</p>`, `<p>sample text that...</p>`},
+		{`<p>sample <b>text that is</b> too long</p>`, `<p>sample <b>text that</b>...</p>`},
+		{`<p>sample <a href="/x"><i>text that is</i></a> too long</p>`, `<p>sample <a href="/x"><i>text that</i></a>...</p>`},
+		{`<div><p>sample text that.</p><p>Has more</p></div>`, `<div><p>sample text that...</p></div>`},
+		{`<p>sample text that? I don't know</p>`, `<p>sample text that?..</p>`},
+		{`<p>sampleTextThatIsTooLongWithNoSpaces</p>`, `<p>sampleTextThatIsT...</p>`},
+		{`<p>Tom &amp; Jerry &amp; Spike &amp; Tyke</p>`, `<p>Tom &amp; Jerry &amp;...</p>`},
+		{`<p>a&lt;b&gt;c&lt;d&gt;e&lt;f&gt;g&lt;h&gt;i&lt;j&gt;k</p>`, `<p>a&lt;b&gt;c&lt;d&gt;e&lt;f&gt;g&lt;h&gt;i...</p>`},
+		{`<ul><li>one two<li>three four<li>five six</ul>`, `<ul><li>one two<li>three...</li></ul>`},
+		{`<p>One</p><p>Two</p><p>Three</p><p>Four</p><p>Five</p>`, `<p>One</p><p>Two</p><p>Three...</p>`},
+		{`<b>sample text that is too long</b>`, `<b>sample text that</b>...`},
+		{`<p><script>var x = "lots of hidden text";</script>sample text that is too long</p>`, `<p><script>var x = "lots of hidden text";</script>sample text that...</p>`},
+		{`<p>sample<br>text<img src="x.png">that is too long</p>`, `<p>sample<br>text<img src="x.png">that...</p>`},
+		{`<p>  sample   text  that   is</p>`, `<p>  sample   text  that   is</p>`},
+		{`<p>Ærø Ærø Ærø Ærø Ærø Ærø</p>`, `<p>Ærø Ærø Ærø Ærø...</p>`},
+		{``, ``},
+	}
+
+	for _, sample := range samples {
+		if out := ShortenHTML(sample.in, 20, "..."); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestShortenHTMLWithoutAppend(t *testing.T) {
+	samples := []sample{
+		{`<p>sample <em>text</em> that is too long</p>`, `<p>sample <em>text</em> that is</p>`},
+		{`<p>sampleTextThatIsTooLongWithNoSpaces</p>`, `<p>sampleTextThatIsTooL</p>`},
+		{`<p>sample text that. Has more</p>`, `<p>sample text that.</p>`},
+	}
+
+	for _, sample := range samples {
+		if out := ShortenHTML(sample.in, 20, ""); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func BenchmarkShortenHTML(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = ShortenHTML(`<p>Some <b>sample</b> text with <a href="https://example.com">a link</a> that is too long</p>`, 20, "...")
+	}
+}
@@ -0,0 +1,548 @@
+package texttools
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/text/width"
+)
+
+// The max number of columns a table cell can span, the same limit browsers use.
+const maxTableColSpan = 1000
+
+// The max number of columns a table can have to be drawn as columns.
+// Every row is padded to the full width, so wider tables, like ones from untrusted HTML with huge colspans,
+// are written as plain text instead of using up all the memory.
+const maxTableColumns = 100
+
+// Words longer than this may be broken when a table column is too narrow for them.
+const maxTableWordWidth = 20
+
+// tableCell is a rendered table cell.
+type tableCell struct {
+	lines []string
+	span  int
+}
+
+// tableRow is a rendered table row.
+type tableRow struct {
+	cells  []tableCell
+	header bool
+}
+
+// tableBorders are the chars used to draw a table.
+type tableBorders struct {
+	horizontal, headerHorizontal, vertical string
+	// Junctions indexed by [pos][up*2+down], where pos is 0 for the top, 1 for the middle and 2 for the bottom.
+	junctions [3][4]string
+	// Junctions below header rows, indexed by up*2+down
+	headerJunctions [4]string
+}
+
+var asciiTableBorders = tableBorders{
+	horizontal:       "-",
+	headerHorizontal: "=",
+	vertical:         "|",
+	junctions: [3][4]string{
+		{"-", "+", "+", "+"},
+		{"-", "+", "+", "+"},
+		{"-", "+", "+", "+"},
+	},
+	headerJunctions: [4]string{"=", "+", "+", "+"},
+}
+
+var unicodeTableBorders = tableBorders{
+	horizontal:       "─",
+	headerHorizontal: "═",
+	vertical:         "│",
+	junctions: [3][4]string{
+		{"─", "┬", "─", "┬"},
+		{"─", "┬", "┴", "┼"},
+		{"─", "─", "┴", "┴"},
+	},
+	headerJunctions: [4]string{"═", "╤", "╧", "╪"},
+}
+
+// Unicode corners and edge junctions, indexed by [row][0 for left, 1 for right].
+var unicodeTableCorners = [3][2]string{
+	{"┌", "┐"},
+	{"├", "┤"},
+	{"└", "┘"},
+}
+
+// Unicode edge junctions below header rows.
+var unicodeTableHeaderCorners = [2]string{"╞", "╡"}
+
+// renderTable writes a table as aligned columns with borders.
+// If the table can't fit within the max width, each row is written as "key: value" lines instead,
+// and if it has more than maxTableColumns columns, each row is written as a line of text.
+func (w *htmlTextWriter) renderTable(n *html.Node) {
+	var caption string
+	var rows []tableRow
+	w.collectTableRows(n, false, &caption, &rows)
+
+	if caption != "" {
+		w.writeText(caption)
+		w.blockBreak(1)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	cols := htmlTableColumns(n)
+	if cols > maxTableColumns {
+		w.writeLines(tableTextLines(rows, " "))
+		return
+	}
+
+	// Pad short rows, so every row covers all the columns
+	for i, row := range rows {
+		n := 0
+		for _, cell := range row.cells {
+			n += cell.span
+		}
+		for ; n < cols; n++ {
+			rows[i].cells = append(rows[i].cells, tableCell{span: 1})
+		}
+	}
+
+	widths, ok := tableColumnWidths(rows, cols, w.opts.MaxWidth)
+	if !ok {
+		w.writeLines(tableKeyValueLines(rows))
+		return
+	}
+
+	borders := asciiTableBorders
+	if w.opts.UnicodeTables {
+		borders = unicodeTableBorders
+	}
+	w.writeLines(drawTable(rows, widths, borders, w.opts.UnicodeTables))
+}
+
+// collectTableRows renders all rows in a table, including the ones in thead, tbody and tfoot.
+func (w *htmlTextWriter) collectTableRows(n *html.Node, header bool, caption *string, rows *[]tableRow) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.DataAtom {
+		case atom.Caption:
+			*caption = strings.Join(strings.Fields(htmlTextContent(c)), " ")
+		case atom.Thead:
+			w.collectTableRows(c, true, caption, rows)
+		case atom.Tbody, atom.Tfoot:
+			w.collectTableRows(c, false, caption, rows)
+		case atom.Tr:
+			*rows = append(*rows, w.tableRow(c, header))
+		}
+	}
+}
+
+// tableRow renders the cells of a row.
+// A row is a header row if it's in thead or only has th cells.
+func (w *htmlTextWriter) tableRow(tr *html.Node, header bool) (row tableRow) {
+	allHeaders := true
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+			continue
+		}
+		if c.DataAtom == atom.Td {
+			allHeaders = false
+		}
+
+		span := tableColSpan(c)
+		cw := &htmlTextWriter{blockWriter: blockWriter{lineStart: true}, opts: w.opts}
+		cw.renderChildren(c)
+		text := strings.Trim(cw.b.String(), "\n")
+
+		var lines []string
+		if text != "" {
+			lines = strings.Split(text, "\n")
+		}
+		row.cells = append(row.cells, tableCell{lines: lines, span: span})
+	}
+
+	row.header = header || (allHeaders && len(row.cells) > 0)
+	return
+}
+
+// htmlTableColumns returns the number of columns in a table, which is the number of columns spanned by its widest row.
+func htmlTableColumns(n *html.Node) (cols int) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		count := 0
+		switch c.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			count = htmlTableColumns(c)
+		case atom.Tr:
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					count += tableColSpan(cell)
+				}
+			}
+		}
+		if count > cols {
+			cols = count
+		}
+	}
+	return
+}
+
+// tableColSpan returns the number of columns a table cell spans, from 1 to maxTableColSpan.
+func tableColSpan(cell *html.Node) int {
+	span, err := strconv.Atoi(htmlAttr(cell, "colspan"))
+	switch {
+	case err != nil || span < 1:
+		return 1
+	case span > maxTableColSpan:
+		return maxTableColSpan
+	}
+	return span
+}
+
+// tableColumnWidths calculates the width of each column.
+// Columns are narrowed, starting with the widest, until the table fits within maxWidth.
+// It returns false if the table can't fit.
+func tableColumnWidths(rows []tableRow, cols, maxWidth int) (widths []int, ok bool) {
+	widths = make([]int, cols)
+	minWidths := make([]int, cols)
+
+	// Start with the cells that only span 1 column, then make room for the rest
+	for _, spanned := range []bool{false, true} {
+		for _, row := range rows {
+			col := 0
+			for _, cell := range row.cells {
+				if (cell.span > 1) == spanned {
+					width, minWidth := cellWidths(cell)
+					growColumns(widths[col:col+cell.span], width)
+					growColumns(minWidths[col:col+cell.span], minWidth)
+				}
+				col += cell.span
+			}
+		}
+	}
+
+	if maxWidth <= 0 {
+		return widths, true
+	}
+
+	// Each column has a space on each side and a border on the right, plus the left border
+	available := maxWidth - 3*cols - 1
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+
+	for total > available {
+		widest := -1
+		for i, width := range widths {
+			if width > minWidths[i] && (widest < 0 || width > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return nil, false
+		}
+		widths[widest]--
+		total--
+	}
+
+	return widths, true
+}
+
+// cellWidths returns the display width of the longest line and the longest word in a cell.
+func cellWidths(cell tableCell) (width, minWidth int) {
+	for _, line := range cell.lines {
+		if l := displayWidth(line); l > width {
+			width = l
+		}
+		for _, word := range strings.Fields(line) {
+			if l := displayWidth(word); l > minWidth {
+				minWidth = l
+			}
+		}
+	}
+	if minWidth > maxTableWordWidth {
+		minWidth = maxTableWordWidth
+	}
+	return
+}
+
+// growColumns makes sure that the columns, including the borders between them, are at least width wide.
+// The missing width is spread over the columns.
+func growColumns(widths []int, width int) {
+	total := 3 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for i := 0; total < width; i = (i + 1) % len(widths) {
+		widths[i]++
+		total++
+	}
+}
+
+// spanWidth returns the width of a cell spanning the columns, including the borders between them.
+func spanWidth(widths []int) int {
+	total := 3 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	return total
+}
+
+// wrapText wraps a line to the given display width, breaking words that are too long.
+// Runs of spaces, like indentation or aligned preformatted text, are kept, except where the line is wrapped.
+func wrapText(line string, width int) (lines []string) {
+	if width < 1 {
+		width = 1
+	}
+
+	var cur strings.Builder
+	curWidth := 0
+	flush := func() {
+		lines = append(lines, cur.String())
+		cur.Reset()
+		curWidth = 0
+	}
+
+	for rest := line; rest != ""; {
+		// Split off the spaces and the word after them
+		i := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsSpace(r) })
+		if i < 0 {
+			break
+		}
+		spaces := rest[:i]
+		rest = rest[i:]
+		j := strings.IndexFunc(rest, unicode.IsSpace)
+		if j < 0 {
+			j = len(rest)
+		}
+		word := rest[:j]
+		rest = rest[j:]
+
+		// Move the word to the next line, where the spaces before it are dropped
+		spacesWidth := displayWidth(spaces)
+		if curWidth > 0 && curWidth+spacesWidth+displayWidth(word) > width {
+			flush()
+			spaces, spacesWidth = "", 0
+		}
+		if curWidth+spacesWidth < width {
+			cur.WriteString(spaces)
+			curWidth += spacesWidth
+		}
+
+		for _, r := range word {
+			if rw := runeWidth(r); curWidth+rw > width && curWidth > 0 {
+				flush()
+			}
+			cur.WriteRune(r)
+			curWidth += runeWidth(r)
+		}
+	}
+
+	if cur.Len() > 0 || len(lines) == 0 {
+		flush()
+	}
+	return
+}
+
+// displayWidth returns the number of columns a string takes up in a terminal.
+func displayWidth(str string) (total int) {
+	for _, r := range str {
+		total += runeWidth(r)
+	}
+	return
+}
+
+// runeWidth returns the number of columns a char takes up in a terminal:
+// 2 for wide East Asian chars and emoji, 0 for combining marks and format chars, like zero width spaces, and 1 for the rest.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// drawTable draws the rows as a table with the given column widths.
+func drawTable(rows []tableRow, widths []int, borders tableBorders, unicode bool) (lines []string) {
+	for i, row := range rows {
+		// The rule above the row
+		pos := 1
+		if i == 0 {
+			pos = 0
+		}
+		var above *tableRow
+		if i > 0 {
+			above = &rows[i-1]
+		}
+		if i == 0 || above.header && !row.header {
+			lines = append(lines, tableRule(above, &row, widths, borders, pos, above != nil && above.header, unicode))
+		}
+
+		// Wrap the cells and find the height of the row
+		wrapped := make([][]string, len(row.cells))
+		height := 1
+		col := 0
+		for j, cell := range row.cells {
+			width := spanWidth(widths[col : col+cell.span])
+			for _, line := range cell.lines {
+				wrapped[j] = append(wrapped[j], wrapText(line, width)...)
+			}
+			if len(wrapped[j]) > height {
+				height = len(wrapped[j])
+			}
+			col += cell.span
+		}
+
+		for l := 0; l < height; l++ {
+			var b strings.Builder
+			b.WriteString(borders.vertical)
+			col := 0
+			for j, cell := range row.cells {
+				width := spanWidth(widths[col : col+cell.span])
+				text := ""
+				if l < len(wrapped[j]) {
+					text = wrapped[j][l]
+				}
+				b.WriteString(" " + text + strings.Repeat(" ", width-displayWidth(text)) + " " + borders.vertical)
+				col += cell.span
+			}
+			lines = append(lines, b.String())
+		}
+	}
+
+	last := rows[len(rows)-1]
+	lines = append(lines, tableRule(&last, nil, widths, borders, 2, false, unicode))
+	return
+}
+
+// tableRule draws a horizontal rule between two rows, either of which may be nil.
+// pos is 0 for the top rule, 1 for rules between rows and 2 for the bottom rule.
+func tableRule(above, below *tableRow, widths []int, borders tableBorders, pos int, header, unicode bool) string {
+	horizontal := borders.horizontal
+	if header {
+		horizontal = borders.headerHorizontal
+	}
+
+	var b strings.Builder
+	switch {
+	case !unicode:
+		b.WriteString("+")
+	case header:
+		b.WriteString(unicodeTableHeaderCorners[0])
+	default:
+		b.WriteString(unicodeTableCorners[pos][0])
+	}
+
+	for i, width := range widths {
+		b.WriteString(strings.Repeat(horizontal, width+2))
+		if i == len(widths)-1 {
+			break
+		}
+
+		junction := 0
+		if above != nil && rowHasBoundary(*above, i+1) {
+			junction += 2
+		}
+		if below != nil && rowHasBoundary(*below, i+1) {
+			junction++
+		}
+		if header {
+			b.WriteString(borders.headerJunctions[junction])
+		} else {
+			b.WriteString(borders.junctions[pos][junction])
+		}
+	}
+
+	switch {
+	case !unicode:
+		b.WriteString("+")
+	case header:
+		b.WriteString(unicodeTableHeaderCorners[1])
+	default:
+		b.WriteString(unicodeTableCorners[pos][1])
+	}
+
+	return b.String()
+}
+
+// rowHasBoundary reports whether a cell in the row starts at the column.
+func rowHasBoundary(row tableRow, col int) bool {
+	n := 0
+	for _, cell := range row.cells {
+		if n == col {
+			return true
+		}
+		n += cell.span
+	}
+	return false
+}
+
+// tableKeyValueLines writes each row as "key: value" lines, using the first header row for the keys.
+// Rows are separated by empty lines.
+func tableKeyValueLines(rows []tableRow) (lines []string) {
+	var keys []string
+	for _, row := range rows {
+		if !row.header {
+			continue
+		}
+		for _, cell := range row.cells {
+			key := strings.Join(cell.lines, " ")
+			for i := 0; i < cell.span; i++ {
+				keys = append(keys, key)
+			}
+		}
+		break
+	}
+
+	for _, row := range rows {
+		if row.header {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		col := 0
+		for _, cell := range row.cells {
+			value := strings.Join(cell.lines, " ")
+			if value != "" {
+				if col < len(keys) && keys[col] != "" {
+					lines = append(lines, keys[col]+": "+value)
+				} else {
+					lines = append(lines, value)
+				}
+			}
+			col += cell.span
+		}
+	}
+
+	return
+}
+
+// tableTextLines writes each row as a line with the text of its cells, separated by sep.
+func tableTextLines(rows []tableRow, sep string) (lines []string) {
+	for _, row := range rows {
+		var cells []string
+		for _, cell := range row.cells {
+			if text := strings.Join(cell.lines, " "); text != "" {
+				cells = append(cells, text)
+			}
+		}
+		if len(cells) > 0 {
+			lines = append(lines, strings.Join(cells, sep))
+		}
+	}
+	return
+}
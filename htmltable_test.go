@@ -0,0 +1,148 @@
+package texttools
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleOrderTable = `<table>
+<caption>Order</caption>
+<thead><tr><th>Item</th><th>Qty</th><th>Price</th></tr></thead>
+<tbody>
+<tr><td>Blue widget with a very long description</td><td>2</td><td>$10.00</td></tr>
+<tr><td colspan="2">Total</td><td>$20.00</td></tr>
+</tbody>
+</table>`
+
+func TestHTMLToTextTable(t *testing.T) {
+	samples := []sample{
+		{sampleOrderTable, "Order\n" +
+			"+------------------------------------------+-----+--------+\n" +
+			"| Item                                     | Qty | Price  |\n" +
+			"+==========================================+=====+========+\n" +
+			"| Blue widget with a very long description | 2   | $10.00 |\n" +
+			"| Total                                          | $20.00 |\n" +
+			"+------------------------------------------------+--------+"},
+		{"<p>Before</p><table><tr><td>a</td><td>b</td></tr><tr><td>ccc</td></tr></table>after", "Before\n\n" +
+			"+-----+---+\n" +
+			"| a   | b |\n" +
+			"| ccc |   |\n" +
+			"+-----+---+\n\n" +
+			"after"},
+		{"<table><tr><td>a<br>b</td><td>c</td></tr></table>", "" +
+			"+---+---+\n" +
+			"| a | c |\n" +
+			"| b |   |\n" +
+			"+---+---+"},
+		{"<table><tr><th>Code</th><th>名前</th></tr><tr><td><pre>if x {\n    y  = 1\n}</pre></td><td>東京タワー</td></tr></table>", "" +
+			"+------------+------------+\n" +
+			"| Code       | 名前       |\n" +
+			"+============+============+\n" +
+			"| if x {     | 東京タワー |\n" +
+			"|     y  = 1 |            |\n" +
+			"| }          |            |\n" +
+			"+------------+------------+"},
+		{"<table></table>", ""},
+	}
+
+	for _, sample := range samples {
+		if out := HTMLToText(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestHTMLToTextWithOptionsTable(t *testing.T) {
+	samples := []struct {
+		opts HTMLTextOptions
+		out  string
+	}{
+		// Wrapped to fit
+		{HTMLTextOptions{MaxWidth: 40}, "Order\n" +
+			"+-----------------------+-----+--------+\n" +
+			"| Item                  | Qty | Price  |\n" +
+			"+=======================+=====+========+\n" +
+			"| Blue widget with a    | 2   | $10.00 |\n" +
+			"| very long description |     |        |\n" +
+			"| Total                       | $20.00 |\n" +
+			"+-----------------------------+--------+"},
+		{HTMLTextOptions{MaxWidth: 40, UnicodeTables: true}, "Order\n" +
+			"┌───────────────────────┬─────┬────────┐\n" +
+			"│ Item                  │ Qty │ Price  │\n" +
+			"╞═══════════════════════╪═════╪════════╡\n" +
+			"│ Blue widget with a    │ 2   │ $10.00 │\n" +
+			"│ very long description │     │        │\n" +
+			"│ Total                       │ $20.00 │\n" +
+			"└─────────────────────────────┴────────┘"},
+		// Too narrow for a table
+		{HTMLTextOptions{MaxWidth: 20}, "Order\n" +
+			"Item: Blue widget with a very long description\n" +
+			"Qty: 2\n" +
+			"Price: $10.00\n" +
+			"\n" +
+			"Item: Total\n" +
+			"Price: $20.00"},
+	}
+
+	for _, sample := range samples {
+		if out := HTMLToTextWithOptions(sampleOrderTable, sample.opts); out != sample.out {
+			t.Errorf("got %q with %+v, expected %q", out, sample.opts, sample.out)
+		}
+	}
+}
+
+func TestHTMLToTextTableHugeColSpan(t *testing.T) {
+	// Tables with more than 100 columns are written as lines of text, with or without a max width
+	in := "<table><tr><td colspan=1000000000>x</td><td>y</td></tr><tr><td>a</td></tr></table>"
+	for _, opts := range []HTMLTextOptions{{MaxWidth: defaultHTMLTextWidth}, {}} {
+		if out := HTMLToTextWithOptions(in, opts); out != "x y\na" {
+			t.Errorf("got %q from %q with %+v, expected %q", out, in, opts, "x y\na")
+		}
+	}
+
+	// Padding every row to the full width would take up gigabytes
+	row := "<tr>" + strings.Repeat("<td colspan=1000>x", 100)
+	in = "<table>" + strings.Repeat(row, 100) + "</table>"
+	for _, opts := range []HTMLTextOptions{{MaxWidth: defaultHTMLTextWidth}, {}} {
+		if out := HTMLToTextWithOptions(in, opts); len(out) > len(in) {
+			t.Errorf("got %d chars from %d chars of HTML with %+v, expected at most %d", len(out), len(in), opts, len(in))
+		}
+	}
+
+	// Tables up to the limit are still drawn
+	in = "<table><tr>" + strings.Repeat("<td>x", 100) + "</table>"
+	lines := strings.Split(HTMLToTextWithOptions(in, HTMLTextOptions{}), "\n")
+	if len(lines) != 3 || len(lines[0]) != 1+100*4 {
+		t.Errorf("got %d lines of %d chars from %q, expected 3 lines of %d chars", len(lines), len(lines[0]), in, 1+100*4)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	samples := []struct {
+		in    string
+		width int
+		out   []string
+	}{
+		{"some text to wrap", 9, []string{"some text", "to wrap"}},
+		{"averyveryverylongword here", 8, []string{"averyver", "yverylon", "gword", "here"}},
+		{"", 5, []string{""}},
+		{"    indented  and   aligned", 30, []string{"    indented  and   aligned"}},
+		{"    indented  and   aligned", 16, []string{"    indented", "and   aligned"}},
+		{"東京 タワー", 6, []string{"東京", "タワー"}},
+		{"東京タワー", 5, []string{"東京", "タワ", "ー"}},
+	}
+
+	for _, sample := range samples {
+		out := wrapText(sample.in, sample.width)
+		if len(out) != len(sample.out) {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+			continue
+		}
+		for i := range out {
+			if out[i] != sample.out[i] {
+				t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package texttools
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements whose content is never shown as text.
+var htmlSkipElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Template: true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Svg:      true,
+	atom.Select:   true,
+}
+
+// Block elements and the number of newlines they are separated from the surrounding text with.
+var htmlBlockElements = map[atom.Atom]int{
+	atom.P:          2,
+	atom.H1:         2,
+	atom.H2:         2,
+	atom.H3:         2,
+	atom.H4:         2,
+	atom.H5:         2,
+	atom.H6:         2,
+	atom.Ul:         2,
+	atom.Ol:         2,
+	atom.Dl:         2,
+	atom.Blockquote: 2,
+	atom.Pre:        2,
+	atom.Table:      2,
+	atom.Hr:         2,
+	atom.Figure:     2,
+	atom.Div:        1,
+	atom.Section:    1,
+	atom.Article:    1,
+	atom.Header:     1,
+	atom.Footer:     1,
+	atom.Nav:        1,
+	atom.Aside:      1,
+	atom.Main:       1,
+	atom.Address:    1,
+	atom.Figcaption: 1,
+	atom.Form:       1,
+	atom.Fieldset:   1,
+	atom.Details:    1,
+	atom.Summary:    1,
+	atom.Li:         1,
+	atom.Dt:         1,
+	atom.Dd:         1,
+}
+
+// HTML whitespace, which is collapsed outside of preformatted text.
+const htmlSpace = " \t\n\r\f"
+
+// The max width of tables rendered by HTMLToText.
+const defaultHTMLTextWidth = 80
+
+// HTMLTextOptions controls how HTMLToTextWithOptions renders HTML.
+type HTMLTextOptions struct {
+	// MaxWidth is the max width of tables, which are wrapped to fit.
+	// Tables that can't fit are rendered as "key: value" lines instead.
+	// 0 means no limit.
+	MaxWidth int
+
+	// UnicodeTables draws tables with box drawing chars instead of ASCII.
+	UnicodeTables bool
+}
+
+// blockWriter writes text as blocks of lines, with indentation for nested blocks.
+type blockWriter struct {
+	b         strings.Builder
+	prefixes  []string // Indentation of the current line, e.g. for list items and blockquotes
+	marker    string   // A list marker waiting to be written in front of the next line
+	breaks    int      // Newlines waiting to be written before the next text
+	space     bool     // A space waiting to be written before the next text
+	hardBreak string   // Written at the end of the line, if the next newline is a single line break
+	lineStart bool
+	written   []string // The prefixes when text was last written
+}
+
+// htmlTextWriter renders a parsed HTML tree as plain text.
+type htmlTextWriter struct {
+	blockWriter
+	pre  int // Depth of preformatted elements
+	opts HTMLTextOptions
+}
+
+// HTMLToTextWithOptions converts HTML to standard text like HTMLToText, but with control over how tables are rendered.
+func HTMLToTextWithOptions(html string, opts HTMLTextOptions) string {
+	return renderHTMLText(html, opts)
+}
+
+// renderHTMLText parses html and renders it as plain text.
+func renderHTMLText(str string, opts HTMLTextOptions) string {
+	doc, err := html.Parse(strings.NewReader(str))
+	if err != nil {
+		return ""
+	}
+	return renderHTMLNodeText(doc, opts)
+}
+
+// renderHTMLNodeText renders parsed HTML as text.
+func renderHTMLNodeText(doc *html.Node, opts HTMLTextOptions) string {
+	w := &htmlTextWriter{blockWriter: blockWriter{lineStart: true}, opts: opts}
+	w.render(doc)
+	return strings.Trim(w.b.String(), "\n")
+}
+
+// render writes a node and all of its children.
+func (w *htmlTextWriter) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre > 0 {
+			w.writeLines(strings.Split(n.Data, "\n"))
+		} else {
+			w.writeText(n.Data)
+		}
+		return
+	case html.DocumentNode:
+		w.renderChildren(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if htmlSkipElements[n.DataAtom] {
+		return
+	}
+
+	breaks := htmlBlockElements[n.DataAtom]
+	if (n.DataAtom == atom.Ul || n.DataAtom == atom.Ol) && n.Parent != nil && n.Parent.DataAtom == atom.Li {
+		// Nested lists are kept tight
+		breaks = 1
+	}
+	w.blockBreak(breaks)
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.breaks++
+		w.space = false
+
+	case atom.Img:
+		if alt := htmlAttr(n, "alt"); alt != "" {
+			w.writeText(alt)
+		}
+
+	case atom.A:
+		w.renderChildren(n)
+		if href := htmlLinkURL(n); href != "" {
+			w.writeText(" (" + href + ")")
+		}
+
+	case atom.Pre:
+		w.pre++
+		w.renderChildren(n)
+		w.pre--
+
+	case atom.Blockquote:
+		w.pushPrefix("> ")
+		w.renderChildren(n)
+		w.popPrefix()
+
+	case atom.Ul, atom.Ol:
+		w.renderList(n)
+
+	case atom.Dd:
+		w.pushPrefix("  ")
+		w.renderChildren(n)
+		w.popPrefix()
+
+	case atom.Table:
+		w.renderTable(n)
+
+	default:
+		w.renderChildren(n)
+	}
+
+	w.blockBreak(breaks)
+}
+
+// renderChildren writes all children of a node.
+func (w *htmlTextWriter) renderChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.render(c)
+	}
+}
+
+// renderList writes the items of an ordered or unordered list with bullets or numbers.
+func (w *htmlTextWriter) renderList(n *html.Node) {
+	num := 1
+	if start, err := strconv.Atoi(htmlAttr(n, "start")); err == nil {
+		num = start
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			w.render(c)
+			continue
+		}
+
+		marker := "* "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+
+		w.startListItem(marker)
+		w.renderChildren(c)
+		w.endListItem()
+	}
+}
+
+// writeText writes text with collapsed whitespace.
+func (w *htmlTextWriter) writeText(str string) {
+	w.writeWords(str, nil)
+}
+
+// pushPrefix indents the following lines.
+func (w *blockWriter) pushPrefix(prefix string) {
+	w.prefixes = append(w.prefixes, prefix)
+}
+
+// popPrefix removes the indentation added by the last call to pushPrefix.
+func (w *blockWriter) popPrefix() {
+	w.prefixes = w.prefixes[:len(w.prefixes)-1]
+}
+
+// startListItem starts a new line with a list marker and indents the following lines to match.
+func (w *blockWriter) startListItem(marker string) {
+	w.blockBreak(1)
+	w.marker = marker
+	w.pushPrefix(strings.Repeat(" ", len(marker)))
+}
+
+// endListItem ends a list item started by startListItem.
+func (w *blockWriter) endListItem() {
+	w.popPrefix()
+	w.marker = ""
+	w.blockBreak(1)
+}
+
+// blockBreak makes sure that the next text is separated from the previous text by at least n newlines.
+func (w *blockWriter) blockBreak(n int) {
+	if n == 0 {
+		return
+	}
+	if n > w.breaks {
+		w.breaks = n
+	}
+	w.space = false
+}
+
+// writeWords writes text with collapsed whitespace.
+// If escape is not nil, each word is passed through it before being written.
+func (w *blockWriter) writeWords(str string, escape func(word string, lineStart bool) string) {
+	if str == "" {
+		return
+	}
+	if strings.ContainsRune(htmlSpace, rune(str[0])) {
+		w.space = true
+	}
+
+	words := strings.FieldsFunc(str, func(r rune) bool {
+		return strings.ContainsRune(htmlSpace, r)
+	})
+
+	for i, word := range words {
+		if i > 0 {
+			w.space = true
+		}
+		lineStart := w.lineStart || w.breaks > 0
+		w.startText()
+		if escape != nil {
+			word = escape(word, lineStart)
+		}
+		w.b.WriteString(word)
+	}
+
+	if len(words) > 0 && strings.ContainsRune(htmlSpace, rune(str[len(str)-1])) {
+		w.space = true
+	}
+}
+
+// writeRaw writes text as is on the current line.
+func (w *blockWriter) writeRaw(str string) {
+	w.startText()
+	w.b.WriteString(str)
+}
+
+// writeLines writes lines of text as is, e.g. preformatted text or a rendered table.
+func (w *blockWriter) writeLines(lines []string) {
+	for i, line := range lines {
+		if i > 0 {
+			w.breaks++
+		}
+		if line != "" {
+			w.startText()
+			w.b.WriteString(line)
+		}
+	}
+}
+
+// startText writes any waiting newlines, indentation and spaces before the next text.
+func (w *blockWriter) startText() {
+	if w.breaks > 0 {
+		// Newlines are never written at the start of the text
+		if w.b.Len() > 0 {
+			// Empty lines only belong to the blocks that both the previous and the next text are in
+			depth := 0
+			for depth < len(w.prefixes) && depth < len(w.written) && w.prefixes[depth] == w.written[depth] {
+				depth++
+			}
+			prefix := strings.TrimRight(strings.Join(w.prefixes[:depth], ""), " ")
+			if w.breaks == 1 && w.hardBreak != "" {
+				w.b.WriteString(w.hardBreak)
+			}
+			w.b.WriteString("\n")
+			for i := 1; i < w.breaks; i++ {
+				w.b.WriteString(prefix + "\n")
+			}
+		}
+		w.breaks = 0
+		w.space = false
+		w.lineStart = true
+		w.hardBreak = ""
+	}
+
+	if w.lineStart {
+		if w.marker != "" && len(w.prefixes) > 0 {
+			w.b.WriteString(strings.Join(w.prefixes[:len(w.prefixes)-1], "") + w.marker)
+			w.marker = ""
+		} else {
+			w.b.WriteString(strings.Join(w.prefixes, ""))
+		}
+		w.lineStart = false
+		w.space = false
+	}
+
+	if w.space {
+		w.b.WriteString(" ")
+		w.space = false
+	}
+
+	w.written = append(w.written[:0], w.prefixes...)
+}
+
+// htmlAttr returns the value of an attribute or an empty string.
+func htmlAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// htmlLinkURL returns the URL to show after the text of a link.
+// Only absolute URLs are shown, as relative URLs mean nothing outside of the page,
+// and the URL is left out if it's the same as the text.
+func htmlLinkURL(n *html.Node) string {
+	href := strings.TrimSpace(htmlAttr(n, "href"))
+	u, err := url.Parse(href)
+	if err != nil || !isTextLinkURL(u) {
+		return ""
+	}
+
+	text := strings.Join(strings.Fields(htmlTextContent(n)), " ")
+	if text == href || text == u.Opaque || strings.TrimSuffix(href, "/") == text {
+		return ""
+	}
+	return href
+}
+
+// isTextLinkURL reports whether a URL is absolute with a scheme that is safe to show in text.
+func isTextLinkURL(u *url.URL) bool {
+	if !u.IsAbs() {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp", "mailto", "tel":
+		return true
+	}
+	return false
+}
+
+// htmlTextContent returns the text of a node and all of its children.
+func htmlTextContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(htmlTextContent(c))
+	}
+	return b.String()
+}
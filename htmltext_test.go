@@ -0,0 +1,41 @@
+package texttools
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	samples := []sample{
+		{"<p>a</p><p>b</p>", "a\n\nb"},
+		{"<div>a\r\n   b</div><div>c</div>", "a b\nc"},
+		{"a<br>b<br><br>c", "a\nb\n\nc"},
+		{"Text <b>with</b> <i>inline</i> elements", "Text with inline elements"},
+		{"Fish &amp; chips &eacute;&#8217;s&nbsp;menu", "Fish & chips é’s menu"},
+		{"<html><head><title>Title</title><style>p { color: red }</style></head><body><script>alert(1)</script><p>Hi</p></body></html>", "Hi"},
+		{"<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul>", "* one\n* two\n  * nested"},
+		{"<ol start=3><li>three</li><li>four</li></ol>", "3. three\n4. four"},
+		{"<ol><li><p>long</p><p>item</p></li></ol>", "1. long\n\n   item"},
+		{`<a href="https://example.com">Example</a>`, "Example (https://example.com)"},
+		{`<a href="https://example.com">https://example.com</a>`, "https://example.com"},
+		{`<a href="mailto:info@example.com">info@example.com</a>`, "info@example.com"},
+		{`<a href="/relative">Relative</a> <a href="javascript:void(0)">JS</a>`, "Relative JS"},
+		{`<img src="cat.png" alt="A cat"><img src="spacer.gif">`, "A cat"},
+		{"<pre>\n  code\n    indented\n</pre>after", "  code\n    indented\n\nafter"},
+		{"<p>before</p><pre>  code</pre>", "before\n\n  code"},
+		{"<blockquote><p>quoted</p><p>more</p></blockquote>", "> quoted\n>\n> more"},
+		{"<p>before</p><blockquote>quoted</blockquote><p>after</p>", "before\n\n> quoted\n\nafter"},
+		{"<dl><dt>Term</dt><dd>Definition</dd></dl>", "Term\n  Definition"},
+		{"<h1>Title</h1>Text<hr>More", "Title\n\nText\n\nMore"},
+		{"  \r\n  ", ""},
+	}
+
+	for _, sample := range samples {
+		if out := HTMLToText(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func BenchmarkHTMLToText(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = HTMLToText(`<h1>Title</h1><p>Some <b>sample</b> text with <a href="https://example.com">a link</a></p><ul><li>one</li><li>two</li></ul>`)
+	}
+}
@@ -0,0 +1,345 @@
+package texttools
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ID errors.
+var (
+	ErrInvalidUUID  = errors.New("texttools: invalid UUID")
+	ErrInvalidULID  = errors.New("texttools: invalid ULID")
+	ErrInvalidKSUID = errors.New("texttools: invalid KSUID")
+	ErrULIDOverflow = errors.New("texttools: monotonic ULID overflow")
+)
+
+// The length of a NanoID, which gives about the same collision probability as a UUID v4.
+const nanoIDLength = 21
+
+// KSUID timestamps are seconds since 2014-05-13 16:53:20 UTC, instead of the Unix epoch.
+const ksuidEpoch = 1400000000
+
+// The base62 alphabet used by KSUID, which sorts like the IDs.
+const ksuidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// The lengths of the string forms of KSUIDs and ULIDs.
+const (
+	ksuidStringLength = 27
+	ulidStringLength  = 26
+)
+
+// The generator used by NewULID.
+var defaultULIDGenerator = NewULIDGenerator(nil, false)
+
+// UUID is a UUID (RFC 9562) in binary form.
+type UUID [16]byte
+
+// ULID is a ULID in binary form: a 48 bit timestamp in milliseconds, followed by 80 random bits.
+type ULID [16]byte
+
+// KSUID is a KSUID in binary form: a 32 bit timestamp in seconds, followed by 128 random bits.
+type KSUID [20]byte
+
+// NewUUIDv4 returns a random UUID (version 4), using the crypto rand package.
+func NewUUIDv4() (u UUID, err error) {
+	if _, err = io.ReadFull(defaultRandomGenerator, u[:]); err != nil {
+		return UUID{}, err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return
+}
+
+// NewUUIDv7 returns a time ordered UUID (version 7), with the current Unix time in milliseconds followed by random bits.
+// UUIDs created within the same millisecond are not ordered.
+func NewUUIDv7() (u UUID, err error) {
+	if _, err = io.ReadFull(defaultRandomGenerator, u[6:]); err != nil {
+		return UUID{}, err
+	}
+	putMillis(u[:6], time.Now())
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return
+}
+
+// ParseUUID parses a UUID in the canonical form, like "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+// Upper case hex digits are allowed.
+func ParseUUID(str string) (u UUID, err error) {
+	if len(str) != 36 || str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
+		return UUID{}, ErrInvalidUUID
+	}
+
+	hexStr := str[:8] + str[9:13] + str[14:18] + str[19:23] + str[24:]
+	if _, err = hex.Decode(u[:], []byte(hexStr)); err != nil {
+		return UUID{}, ErrInvalidUUID
+	}
+	return
+}
+
+// UUIDFromBytes returns the UUID of 16 bytes.
+func UUIDFromBytes(b []byte) (u UUID, err error) {
+	if len(b) != len(u) {
+		return UUID{}, ErrInvalidUUID
+	}
+	copy(u[:], b)
+	return
+}
+
+// IsValidUUID reports whether a string is a UUID in the canonical form.
+func IsValidUUID(str string) bool {
+	_, err := ParseUUID(str)
+	return err == nil
+}
+
+// String returns the UUID in the canonical form, with lower case hex digits.
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[:8], u[:4])
+	hex.Encode(b[9:13], u[4:6])
+	hex.Encode(b[14:18], u[6:8])
+	hex.Encode(b[19:23], u[8:10])
+	hex.Encode(b[24:], u[10:])
+	b[8], b[13], b[18], b[23] = '-', '-', '-', '-'
+	return string(b[:])
+}
+
+// Version returns the version of the UUID, like 4 or 7.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time returns the time of a version 7 UUID, or the zero time for other versions.
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+	return millisTime(u[:6])
+}
+
+// ULIDGenerator creates ULIDs from random bytes read from a reader.
+// In monotonic mode, ULIDs created within the same millisecond are ordered,
+// as the random bits of the last ULID are incremented instead of being read again.
+// It's safe for concurrent use.
+type ULIDGenerator struct {
+	mu        sync.Mutex
+	random    *RandomGenerator
+	monotonic bool
+	last      ULID
+}
+
+// NewULIDGenerator returns a ULID generator, which reads random bytes from r.
+// A nil r uses the crypto rand package. Deterministic readers can be used in tests.
+func NewULIDGenerator(r io.Reader, monotonic bool) *ULIDGenerator {
+	return &ULIDGenerator{random: NewRandomGenerator(r), monotonic: monotonic}
+}
+
+// New returns a ULID with the current time.
+func (g *ULIDGenerator) New() (ULID, error) {
+	return g.NewAt(time.Now())
+}
+
+// NewAt returns a ULID with the given time.
+// In monotonic mode, ErrULIDOverflow is returned if the random bits can't be incremented any more within the millisecond.
+func (g *ULIDGenerator) NewAt(t time.Time) (u ULID, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	putMillis(u[:6], t)
+	if g.monotonic && g.last != (ULID{}) && string(u[:6]) == string(g.last[:6]) {
+		u = g.last
+		for i := len(u) - 1; ; i-- {
+			if i < 6 {
+				return ULID{}, ErrULIDOverflow
+			}
+			u[i]++
+			if u[i] != 0 {
+				break
+			}
+		}
+	} else if _, err = io.ReadFull(g.random, u[6:]); err != nil {
+		return ULID{}, err
+	}
+
+	g.last = u
+	return
+}
+
+// NewULID returns a ULID with the current time and random bits from the crypto rand package.
+// ULIDs created within the same millisecond are not ordered, use a monotonic ULIDGenerator for that.
+func NewULID() (ULID, error) {
+	return defaultULIDGenerator.New()
+}
+
+// ParseULID parses a ULID in the string form, 26 chars of Crockford's base32, like "01ARZ3NDEKTSV4RRFFQ69G5FAV".
+// Lower case chars are allowed.
+func ParseULID(str string) (u ULID, err error) {
+	if len(str) != ulidStringLength {
+		return ULID{}, ErrInvalidULID
+	}
+
+	var hi, lo uint64
+	for i := 0; i < len(str); i++ {
+		v := strings.IndexByte(AlphabetCrockford, upperASCII(str[i]))
+		// The first char can only hold 3 bits, as the 26 chars can hold 130 bits
+		if v < 0 || i == 0 && v > 7 {
+			return ULID{}, ErrInvalidULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return
+}
+
+// ULIDFromBytes returns the ULID of 16 bytes.
+func ULIDFromBytes(b []byte) (u ULID, err error) {
+	if len(b) != len(u) {
+		return ULID{}, ErrInvalidULID
+	}
+	copy(u[:], b)
+	return
+}
+
+// IsValidULID reports whether a string is a ULID in the string form.
+func IsValidULID(str string) bool {
+	_, err := ParseULID(str)
+	return err == nil
+}
+
+// String returns the ULID as 26 chars of Crockford's base32.
+func (u ULID) String() string {
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+
+	var b [ulidStringLength]byte
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = AlphabetCrockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(b[:])
+}
+
+// Time returns the time of the ULID, with millisecond precision.
+func (u ULID) Time() time.Time {
+	return millisTime(u[:6])
+}
+
+// NewNanoID returns a NanoID: 21 random chars from the URL safe alphabet, using the crypto rand package.
+func NewNanoID() (string, error) {
+	return defaultRandomGenerator.String(nanoIDLength, AlphabetURLSafe)
+}
+
+// IsValidNanoID reports whether a string is a NanoID of the standard length and alphabet.
+func IsValidNanoID(str string) bool {
+	if len(str) != nanoIDLength {
+		return false
+	}
+	for i := 0; i < len(str); i++ {
+		if strings.IndexByte(AlphabetURLSafe, str[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// NewKSUID returns a KSUID with the current time and random bits from the crypto rand package.
+func NewKSUID() (k KSUID, err error) {
+	if _, err = io.ReadFull(defaultRandomGenerator, k[4:]); err != nil {
+		return KSUID{}, err
+	}
+	binary.BigEndian.PutUint32(k[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	return
+}
+
+// ParseKSUID parses a KSUID in the string form, 27 chars of base62, like "0ujtsYcgvSTl8PAuAdqWYSMnLOv".
+func ParseKSUID(str string) (k KSUID, err error) {
+	if len(str) != ksuidStringLength {
+		return KSUID{}, ErrInvalidKSUID
+	}
+
+	n := new(big.Int)
+	base := big.NewInt(int64(len(ksuidAlphabet)))
+	for i := 0; i < len(str); i++ {
+		v := strings.IndexByte(ksuidAlphabet, str[i])
+		if v < 0 {
+			return KSUID{}, ErrInvalidKSUID
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(v)))
+	}
+
+	// 27 chars of base62 can hold a bit more than the 160 bits
+	if n.BitLen() > len(k)*8 {
+		return KSUID{}, ErrInvalidKSUID
+	}
+	n.FillBytes(k[:])
+	return
+}
+
+// KSUIDFromBytes returns the KSUID of 20 bytes.
+func KSUIDFromBytes(b []byte) (k KSUID, err error) {
+	if len(b) != len(k) {
+		return KSUID{}, ErrInvalidKSUID
+	}
+	copy(k[:], b)
+	return
+}
+
+// IsValidKSUID reports whether a string is a KSUID in the string form.
+func IsValidKSUID(str string) bool {
+	_, err := ParseKSUID(str)
+	return err == nil
+}
+
+// String returns the KSUID as 27 chars of base62.
+func (k KSUID) String() string {
+	n := new(big.Int).SetBytes(k[:])
+	base := big.NewInt(int64(len(ksuidAlphabet)))
+	mod := new(big.Int)
+
+	var b [ksuidStringLength]byte
+	for i := len(b) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		b[i] = ksuidAlphabet[mod.Int64()]
+	}
+	return string(b[:])
+}
+
+// Time returns the time of the KSUID, with second precision.
+func (k KSUID) Time() time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(k[:4]))+ksuidEpoch, 0)
+}
+
+// putMillis writes the Unix time in milliseconds as 6 bytes, big endian.
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// millisTime returns the time of a Unix time in milliseconds, written as 6 bytes, big endian.
+func millisTime(b []byte) time.Time {
+	var ms int64
+	for _, c := range b[:6] {
+		ms = ms<<8 | int64(c)
+	}
+	return time.Unix(ms/1000, ms%1000*int64(time.Millisecond))
+}
+
+// upperASCII returns the upper case of an ASCII letter, or the char itself.
+func upperASCII(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
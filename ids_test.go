@@ -0,0 +1,196 @@
+package texttools
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestUUID(t *testing.T) {
+	for _, version := range []int{4, 7} {
+		newUUID := NewUUIDv4
+		if version == 7 {
+			newUUID = NewUUIDv7
+		}
+
+		before := time.Now().Truncate(time.Millisecond)
+		u, err := newUUID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := u.Version(); v != version {
+			t.Errorf("got version %d from %s, expected %d", v, u, version)
+		}
+		if u[8]>>6 != 2 {
+			t.Errorf("got variant %b from %s, expected 10", u[8]>>6, u)
+		}
+		if version == 7 && (u.Time().Before(before) || u.Time().After(time.Now())) {
+			t.Errorf("got time %v from %s, expected about %v", u.Time(), u, before)
+		}
+
+		parsed, err := ParseUUID(u.String())
+		if err != nil || parsed != u {
+			t.Errorf("got %s and error %v from %q, expected %s", parsed, err, u.String(), u)
+		}
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	samples := []sample{
+		{"f81d4fae-7dec-11d0-a765-00a0c91e6bf6", "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"},
+		{"F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6", "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"},
+		{"f81d4fae7dec11d0a76500a0c91e6bf6", ""},
+		{"f81d4fae-7dec-11d0-a765-00a0c91e6bfg", ""},
+		{"f81d4fae-7dec-11d0-a765_00a0c91e6bf6", ""},
+		{"{f81d4fae-7dec-11d0-a765-00a0c91e6bf6}", ""},
+	}
+
+	for _, sample := range samples {
+		u, err := ParseUUID(sample.in)
+		if sample.out == "" {
+			if err == nil || IsValidUUID(sample.in) {
+				t.Errorf("got %s from %q, expected an error", u, sample.in)
+			}
+			continue
+		}
+		if out := u.String(); err != nil || out != sample.out {
+			t.Errorf("got %q and error %v from %q, expected %q", out, err, sample.in, sample.out)
+		}
+	}
+
+	u, _ := ParseUUID("f81d4fae-7dec-11d0-a765-00a0c91e6bf6")
+	if fromBytes, err := UUIDFromBytes(u[:]); err != nil || fromBytes != u {
+		t.Errorf("got %s and error %v from the bytes of %s", fromBytes, err, u)
+	}
+	if _, err := UUIDFromBytes(u[:15]); err != ErrInvalidUUID {
+		t.Errorf("got error %v from 15 bytes, expected %v", err, ErrInvalidUUID)
+	}
+}
+
+func TestULID(t *testing.T) {
+	u, err := ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms := u.Time().UnixNano() / int64(time.Millisecond); ms != 1469922850259 {
+		t.Errorf("got time %d from %s, expected 1469922850259", ms, u)
+	}
+	if lower, _ := ParseULID("01arz3ndektsv4rrffq69g5fav"); lower != u {
+		t.Errorf("got %s from the lower case form, expected %s", lower, u)
+	}
+
+	invalid := []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "01ARZ3NDEKTSV4RRFFQ69G5FAV0"}
+	for _, str := range invalid {
+		if IsValidULID(str) {
+			t.Errorf("got valid from %q, expected invalid", str)
+		}
+	}
+	if !IsValidULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ") {
+		t.Errorf("got invalid from the largest ULID, expected valid")
+	}
+
+	now := time.Now()
+	u, err = NewULID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := u.Time().Sub(now); d < -time.Millisecond || d > time.Second {
+		t.Errorf("got time %v from %s, expected about %v", u.Time(), u, now)
+	}
+	if parsed, err := ParseULID(u.String()); err != nil || parsed != u {
+		t.Errorf("got %s and error %v from %q, expected %s", parsed, err, u.String(), u)
+	}
+	if fromBytes, err := ULIDFromBytes(u[:]); err != nil || fromBytes != u {
+		t.Errorf("got %s and error %v from the bytes of %s", fromBytes, err, u)
+	}
+}
+
+func TestMonotonicULID(t *testing.T) {
+	random := append(bytes.Repeat([]byte{0}, 9), 0xfe)
+	random = append(random, bytes.Repeat([]byte{0xff}, 10)...)
+	g := NewULIDGenerator(bytes.NewReader(random), true)
+	now := time.Unix(1469922850, 259*int64(time.Millisecond))
+
+	expected := []string{
+		"01ARZ3NDEK000000000000007Y",
+		"01ARZ3NDEK000000000000007Z",
+		"01ARZ3NDEK0000000000000080",
+	}
+	for _, str := range expected {
+		u, err := g.NewAt(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.String() != str {
+			t.Errorf("got %s, expected %s", u, str)
+		}
+	}
+
+	// A new millisecond reads new random bits, which can overflow within the millisecond
+	later := now.Add(time.Millisecond)
+	if u, err := g.NewAt(later); err != nil || u.String() != "01ARZ3NDEMZZZZZZZZZZZZZZZZ" {
+		t.Errorf("got %s and error %v, expected 01ARZ3NDEMZZZZZZZZZZZZZZZZ", u, err)
+	}
+	if _, err := g.NewAt(later); err != ErrULIDOverflow {
+		t.Errorf("got error %v, expected %v", err, ErrULIDOverflow)
+	}
+}
+
+func TestNanoID(t *testing.T) {
+	id, err := NewNanoID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsValidNanoID(id) {
+		t.Errorf("got invalid NanoID %q", id)
+	}
+
+	invalid := []string{"", "V1StGXR8_Z5jdHi6B-my", "V1StGXR8_Z5jdHi6B-myTT", "V1StGXR8_Z5jdHi6B+myT"}
+	for _, str := range invalid {
+		if IsValidNanoID(str) {
+			t.Errorf("got valid from %q, expected invalid", str)
+		}
+	}
+}
+
+func TestKSUID(t *testing.T) {
+	k, err := ParseKSUID("0ujtsYcgvSTl8PAuAdqWYSMnLOv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := k.Time().Unix(); s != 1507608047 {
+		t.Errorf("got time %d from %s, expected 1507608047", s, k)
+	}
+	if payload := fmt.Sprintf("%X", k[4:]); payload != "B5A1CD34B5F99D1154FB6853345C9735" {
+		t.Errorf("got payload %s from %s, expected B5A1CD34B5F99D1154FB6853345C9735", payload, k)
+	}
+	if k.String() != "0ujtsYcgvSTl8PAuAdqWYSMnLOv" {
+		t.Errorf("got %s, expected 0ujtsYcgvSTl8PAuAdqWYSMnLOv", k)
+	}
+
+	valid := []string{"000000000000000000000000000", "aWgEPTl1tmebfsQzFP4bxwgy80V"}
+	for _, str := range valid {
+		if k, err := ParseKSUID(str); err != nil || k.String() != str {
+			t.Errorf("got %s and error %v from %q, expected %s", k, err, str, str)
+		}
+	}
+	invalid := []string{"", "0ujtsYcgvSTl8PAuAdqWYSMnLO", "aWgEPTl1tmebfsQzFP4bxwgy80W", "0ujtsYcgvSTl8PAuAdqWYSMnLO-"}
+	for _, str := range invalid {
+		if IsValidKSUID(str) {
+			t.Errorf("got valid from %q, expected invalid", str)
+		}
+	}
+
+	now := time.Now()
+	k, err = NewKSUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := k.Time().Sub(now); d < -time.Second || d > time.Second {
+		t.Errorf("got time %v from %s, expected about %v", k.Time(), k, now)
+	}
+	if fromBytes, err := KSUIDFromBytes(k[:]); err != nil || fromBytes != k {
+		t.Errorf("got %s and error %v from the bytes of %s", fromBytes, err, k)
+	}
+}
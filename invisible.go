@@ -0,0 +1,206 @@
+package texttools
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InvisibleCategory is the kind of an invisible or dangerous char.
+type InvisibleCategory int
+
+// The categories of invisible chars.
+const (
+	CategoryControl           InvisibleCategory = iota + 1 // C0 and C1 control chars, except tabs and newlines
+	CategorySoftHyphen                                     // U+00AD, which is only shown at line breaks
+	CategoryBOM                                            // U+FEFF byte order mark, also called zero width no-break space
+	CategoryBidiControl                                    // Bidi embeddings, overrides and isolates, as used by "Trojan Source" attacks
+	CategoryBidiMark                                       // Bidi marks, like U+200E left-to-right mark
+	CategoryZeroWidth                                      // Zero width spaces, word joiners and invisible math operators
+	CategoryJoiner                                         // Zero width joiner and non-joiner, which are needed by emoji and some scripts
+	CategoryVariationSelector                              // Variation selectors, which choose between glyphs, like text or emoji style
+	CategoryTag                                            // Tag chars, which are only used in flag emoji
+	CategoryFiller                                         // Chars that look like spaces but aren't, like the Hangul fillers
+	CategoryFormat                                         // Other invisible format chars
+)
+
+// InvisibleMode controls which chars are removed or escaped.
+type InvisibleMode int
+
+// The modes.
+const (
+	// InvisibleDisplay removes the chars that are never needed in text, like display names,
+	// but keeps the ones needed by emoji and some scripts: joiners, variation selectors,
+	// bidi marks and tags in flag emoji.
+	InvisibleDisplay InvisibleMode = iota
+
+	// InvisibleStrict removes all invisible chars, e.g. for identifiers.
+	InvisibleStrict
+)
+
+// InvisibleChar is an invisible or dangerous char found by FindInvisibleChars.
+type InvisibleChar struct {
+	Rune     rune
+	Offset   int // The byte offset in the string
+	Category InvisibleCategory
+}
+
+// The black flag emoji and the cancel tag, which start and end the tag sequence flags, like the flag of England.
+const (
+	tagFlagBase = '\U0001F3F4'
+	tagCancel   = '\U000E007F'
+)
+
+// String returns the name of the category.
+func (c InvisibleCategory) String() string {
+	switch c {
+	case CategoryControl:
+		return "control"
+	case CategorySoftHyphen:
+		return "soft hyphen"
+	case CategoryBOM:
+		return "byte order mark"
+	case CategoryBidiControl:
+		return "bidi control"
+	case CategoryBidiMark:
+		return "bidi mark"
+	case CategoryZeroWidth:
+		return "zero width"
+	case CategoryJoiner:
+		return "joiner"
+	case CategoryVariationSelector:
+		return "variation selector"
+	case CategoryTag:
+		return "tag"
+	case CategoryFiller:
+		return "filler"
+	case CategoryFormat:
+		return "format"
+	}
+	return "unknown"
+}
+
+// FindInvisibleChars returns all invisible and dangerous chars in a string, with their positions and categories.
+// Tabs, newlines and carriage returns are not included.
+func FindInvisibleChars(str string) (found []InvisibleChar) {
+	for i, r := range str {
+		if category := invisibleCategory(r); category != 0 {
+			found = append(found, InvisibleChar{Rune: r, Offset: i, Category: category})
+		}
+	}
+	return
+}
+
+// HasInvisibleChars reports whether a string contains any invisible or dangerous chars.
+func HasInvisibleChars(str string) bool {
+	for _, r := range str {
+		if invisibleCategory(r) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// StripInvisibleChars removes invisible and dangerous chars, depending on the mode.
+func StripInvisibleChars(str string, mode InvisibleMode) string {
+	return replaceInvisibleChars(str, mode, func(r rune) string {
+		return ""
+	})
+}
+
+// EscapeInvisibleChars replaces invisible and dangerous chars with their code point, like <U+202E>,
+// depending on the mode, so they can be seen, e.g. in code review.
+func EscapeInvisibleChars(str string, mode InvisibleMode) string {
+	return replaceInvisibleChars(str, mode, func(r rune) string {
+		return fmt.Sprintf("<U+%04X>", r)
+	})
+}
+
+// replaceInvisibleChars replaces the invisible chars that the mode doesn't allow.
+func replaceInvisibleChars(str string, mode InvisibleMode, replace func(r rune) string) string {
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for i := 0; i < len(str); {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		i += size
+
+		category := invisibleCategory(r)
+		keep := category == 0
+		if mode == InvisibleDisplay {
+			switch category {
+			case CategoryJoiner, CategoryVariationSelector, CategoryBidiMark:
+				keep = true
+			}
+		}
+
+		if keep {
+			b.WriteRune(r)
+		} else {
+			b.WriteString(replace(r))
+		}
+
+		// Tags are only kept in a whole tag sequence flag, like the flag of England
+		if r == tagFlagBase && mode == InvisibleDisplay {
+			n := flagTagSequence(str[i:])
+			b.WriteString(str[i : i+n])
+			i += n
+		}
+	}
+
+	return b.String()
+}
+
+// flagTagSequence returns the length in bytes of the tag sequence of a flag at the start of str, or 0 if there is none.
+// A tag sequence is 2 - 6 tag digits or lower case letters, followed by the cancel tag.
+func flagTagSequence(str string) int {
+	for i, r := range str {
+		switch {
+		case r == tagCancel:
+			if tags := i / utf8.RuneLen(tagCancel); tags >= 2 && tags <= 6 {
+				return i + utf8.RuneLen(tagCancel)
+			}
+			return 0
+		case i >= 6*utf8.RuneLen(tagCancel):
+			return 0
+		case r >= 0xe0030 && r <= 0xe0039, r >= 0xe0061 && r <= 0xe007a:
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// invisibleCategory returns the category of an invisible char, or 0 if the char isn't invisible.
+func invisibleCategory(r rune) InvisibleCategory {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return 0
+	case r < 0x20 || r >= 0x7f && r <= 0x9f:
+		return CategoryControl
+	case r < 0xad:
+		return 0
+	case r == 0xad:
+		return CategorySoftHyphen
+	case r == 0xfeff:
+		return CategoryBOM
+	case r >= 0x202a && r <= 0x202e, r >= 0x2066 && r <= 0x2069:
+		return CategoryBidiControl
+	case r == 0x200e, r == 0x200f, r == 0x061c:
+		return CategoryBidiMark
+	case r == 0x200b, r == 0x2060, r == 0x180e, r >= 0x2061 && r <= 0x2064:
+		return CategoryZeroWidth
+	case r == 0x200c, r == 0x200d:
+		return CategoryJoiner
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0xe0100 && r <= 0xe01ef, r >= 0x180b && r <= 0x180d, r == 0x180f:
+		return CategoryVariationSelector
+	case r == 0xe0001, r >= 0xe0020 && r <= 0xe007f:
+		return CategoryTag
+	case r == 0x034f, r == 0x115f, r == 0x1160, r == 0x3164, r == 0xffa0, r == 0x2800:
+		return CategoryFiller
+	case unicode.Is(unicode.Cf, r):
+		return CategoryFormat
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package texttools
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The flag of England, which is a tag sequence
+const englandFlag = "\U0001F3F4\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F"
+
+func TestFindInvisibleChars(t *testing.T) {
+	in := "a\u200bb\u00adc\ufeffd\u202ee\u200ff\u200dg\ufe0fh\U000E0041i\u3164j\u2063k\x00l\u0085m\t\n\r"
+	expected := []InvisibleChar{
+		{'\u200b', 1, CategoryZeroWidth},
+		{'\u00ad', 5, CategorySoftHyphen},
+		{'\ufeff', 8, CategoryBOM},
+		{'\u202e', 12, CategoryBidiControl},
+		{'\u200f', 16, CategoryBidiMark},
+		{'\u200d', 20, CategoryJoiner},
+		{'\ufe0f', 24, CategoryVariationSelector},
+		{'\U000E0041', 28, CategoryTag},
+		{'\u3164', 33, CategoryFiller},
+		{'\u2063', 37, CategoryZeroWidth},
+		{'\x00', 41, CategoryControl},
+		{'\u0085', 43, CategoryControl},
+	}
+
+	if found := FindInvisibleChars(in); !reflect.DeepEqual(found, expected) {
+		t.Errorf("got %v from %q, expected %v", found, in, expected)
+	}
+	if !HasInvisibleChars(in) {
+		t.Errorf("expected invisible chars in %q", in)
+	}
+
+	clean := "Plain text, tabs\tand newlines\r\n, æøå 😀  "
+	if found := FindInvisibleChars(clean); found != nil {
+		t.Errorf("got %v from %q, expected nothing", found, clean)
+	}
+	if HasInvisibleChars(clean) {
+		t.Errorf("expected no invisible chars in %q", clean)
+	}
+
+	if s := CategoryBidiControl.String(); s != "bidi control" {
+		t.Errorf("got %q, expected %q", s, "bidi control")
+	}
+}
+
+func TestStripInvisibleChars(t *testing.T) {
+	samples := []struct {
+		in, display, strict string
+	}{
+		{"Zero\u200bwidth\u2060 soft\u00adhyphen\ufeff", "Zerowidth softhyphen", "Zerowidth softhyphen"},
+		{"access_level != \"user\u202e \u2066// Check if admin\u2069 \u2066\"", "access_level != \"user // Check if admin \"", "access_level != \"user // Check if admin \""},
+		{"Bell\x07 and\x1b[31m red\u0085", "Bell and[31m red", "Bell and[31m red"},
+		{"👨\u200d👩\u200d👧 ❤\ufe0f \u200fשלום", "👨\u200d👩\u200d👧 ❤\ufe0f \u200fשלום", "👨👩👧 ❤ שלום"},
+		{"Flag " + englandFlag + " and \U000E0068\U000E0069 tags", "Flag " + englandFlag + " and  tags", "Flag \U0001F3F4 and  tags"},
+		{"Unterminated \U0001F3F4\U000E0069\U000E0067\U000E006E\U000E006F\U000E0072\U000E0065 ok", "Unterminated \U0001F3F4 ok", "Unterminated \U0001F3F4 ok"},
+		{"Overlong \U0001F3F4\U000E0069\U000E0067\U000E006E\U000E006F\U000E0072\U000E0065\U000E0073\U000E007F", "Overlong \U0001F3F4", "Overlong \U0001F3F4"},
+		{"Not a flag \U0001F3F4\U000E0047\U000E0042\U000E007F", "Not a flag \U0001F3F4", "Not a flag \U0001F3F4"},
+		{"Hangul\u3164filler", "Hangulfiller", "Hangulfiller"},
+		{"Tabs\tand\nnewlines", "Tabs\tand\nnewlines", "Tabs\tand\nnewlines"},
+	}
+
+	for _, sample := range samples {
+		if out := StripInvisibleChars(sample.in, InvisibleDisplay); out != sample.display {
+			t.Errorf("got %q from %q in display mode, expected %q", out, sample.in, sample.display)
+		}
+		if out := StripInvisibleChars(sample.in, InvisibleStrict); out != sample.strict {
+			t.Errorf("got %q from %q in strict mode, expected %q", out, sample.in, sample.strict)
+		}
+	}
+}
+
+func TestEscapeInvisibleChars(t *testing.T) {
+	in := "if user\u202e\u2066 {\u200d\U000E0041"
+	samples := []struct {
+		mode InvisibleMode
+		out  string
+	}{
+		{InvisibleDisplay, "if user<U+202E><U+2066> {\u200d<U+E0041>"},
+		{InvisibleStrict, "if user<U+202E><U+2066> {<U+200D><U+E0041>"},
+	}
+
+	for _, sample := range samples {
+		if out := EscapeInvisibleChars(in, sample.mode); out != sample.out {
+			t.Errorf("got %q from %q with mode %d, expected %q", out, in, sample.mode, sample.out)
+		}
+	}
+
+	// Tags after the black flag that are not a flag are escaped as well
+	in = "hi \U0001F3F4\U000E0069\U000E0067\U000E006E\U000E006F\U000E0072\U000E0065 ok"
+	expected := "hi \U0001F3F4<U+E0069><U+E0067><U+E006E><U+E006F><U+E0072><U+E0065> ok"
+	if out := EscapeInvisibleChars(in, InvisibleDisplay); out != expected {
+		t.Errorf("got %q from %q, expected %q", out, in, expected)
+	}
+}
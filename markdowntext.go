@@ -0,0 +1,265 @@
+package texttools
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"golang.org/x/net/html/atom"
+)
+
+// MarkdownTextOptions controls how MarkdownToTextWithOptions renders Markdown.
+type MarkdownTextOptions struct {
+	// LinkURLs adds the URL of absolute links after the link text, e.g. "text (url)".
+	LinkURLs bool
+}
+
+var (
+	reRawHTMLBreak = regexp.MustCompile(`(?i)^<br\s*/?>$`)
+	reRawHTMLTag   = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*?(/?)>$`)
+)
+
+// markdownParser parses CommonMark with the GFM extensions (tables, strikethrough, autolinks and task lists).
+var markdownParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// markdownTextWriter renders a parsed Markdown tree as plain text.
+type markdownTextWriter struct {
+	blockWriter
+	source []byte
+	opts   MarkdownTextOptions
+	skip   atom.Atom // An open inline HTML element whose content is dropped, like script
+}
+
+// MarkdownToText converts Markdown (CommonMark and GFM) to standard text.
+// Markup is removed, links are replaced by their text, images by their alt text,
+// lists get bullets or numbers and the content of code is kept.
+// The result is suitable for e.g. Shorten.
+func MarkdownToText(md string) string {
+	return MarkdownToTextWithOptions(md, MarkdownTextOptions{})
+}
+
+// MarkdownToTextWithOptions converts Markdown to standard text like MarkdownToText, but with control over
+// how links are rendered.
+func MarkdownToTextWithOptions(md string, opts MarkdownTextOptions) string {
+	source := []byte(md)
+	doc := markdownParser.Parse(text.NewReader(source))
+
+	w := &markdownTextWriter{blockWriter: blockWriter{lineStart: true}, source: source, opts: opts}
+	w.render(doc)
+	return strings.Trim(w.b.String(), "\n")
+}
+
+// render writes a node and all of its children.
+func (w *markdownTextWriter) render(n ast.Node) {
+	// The content of inline elements like script is dropped until the end tag or the end of the block
+	if n.Type() == ast.TypeBlock {
+		w.skip = 0
+	} else if _, ok := n.(*ast.RawHTML); !ok && w.skip != 0 {
+		return
+	}
+
+	switch n := n.(type) {
+	case *ast.Document:
+		w.renderChildren(n)
+
+	case *ast.Paragraph, *ast.Heading:
+		w.blockBreak(2)
+		w.renderChildren(n)
+		w.blockBreak(2)
+
+	case *ast.TextBlock:
+		w.blockBreak(1)
+		w.renderChildren(n)
+		w.blockBreak(1)
+
+	case *ast.ThematicBreak:
+		w.blockBreak(2)
+
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		w.blockBreak(2)
+		w.writeLines(strings.Split(strings.TrimSuffix(w.blockText(n), "\n"), "\n"))
+		w.blockBreak(2)
+
+	case *ast.HTMLBlock:
+		w.blockBreak(2)
+		if str := renderHTMLText(w.blockText(n), HTMLTextOptions{MaxWidth: defaultHTMLTextWidth}); str != "" {
+			w.writeLines(strings.Split(str, "\n"))
+		}
+		w.blockBreak(2)
+
+	case *ast.Blockquote:
+		w.blockBreak(2)
+		w.pushPrefix("> ")
+		w.renderChildren(n)
+		w.popPrefix()
+		w.blockBreak(2)
+
+	case *ast.List:
+		w.renderList(n)
+
+	case *extast.Table:
+		w.blockBreak(2)
+		w.renderTable(n)
+		w.blockBreak(2)
+
+	case *ast.Text:
+		w.writeWords(string(unescapeMarkdown(n.Value(w.source))), nil)
+		switch {
+		case n.HardLineBreak():
+			w.breaks++
+		case n.SoftLineBreak():
+			w.space = true
+		}
+
+	case *ast.String:
+		w.writeWords(string(n.Value), nil)
+
+	case *ast.CodeSpan:
+		var b bytes.Buffer
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if t, ok := c.(*ast.Text); ok {
+				b.Write(t.Value(w.source))
+			}
+		}
+		w.writeWords(b.String(), nil)
+
+	case *ast.Link:
+		w.renderChildren(n)
+		if dest := w.linkURL(n); dest != "" {
+			w.writeWords(" ("+dest+")", nil)
+		}
+
+	case *ast.AutoLink:
+		w.writeWords(string(n.Label(w.source)), nil)
+
+	case *ast.RawHTML:
+		// Inline HTML tags are dropped, but line breaks are kept, and the content of e.g. script is dropped as well
+		var b bytes.Buffer
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			b.Write(seg.Value(w.source))
+		}
+		tag := bytes.TrimSpace(b.Bytes())
+		if reRawHTMLBreak.Match(tag) {
+			w.breaks++
+		}
+		if m := reRawHTMLTag.FindSubmatch(tag); m != nil {
+			a := atom.Lookup(bytes.ToLower(m[2]))
+			switch {
+			case w.skip == 0 && len(m[1]) == 0 && htmlSkipElements[a] && !(a == atom.Svg && len(m[3]) > 0):
+				w.skip = a
+			case w.skip != 0 && len(m[1]) > 0 && a == w.skip:
+				w.skip = 0
+			}
+		}
+
+	case *extast.TaskCheckBox:
+		if n.IsChecked {
+			w.writeRaw("[x]")
+		} else {
+			w.writeRaw("[ ]")
+		}
+		w.space = true
+
+	default:
+		// Emphasis, strikethrough, images and anything else is replaced by its content
+		w.renderChildren(n)
+	}
+}
+
+// renderChildren writes all children of a node.
+func (w *markdownTextWriter) renderChildren(n ast.Node) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		w.render(c)
+	}
+}
+
+// blockText returns the raw text of a block, e.g. a code block.
+func (w *markdownTextWriter) blockText(n ast.Node) string {
+	var b bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.Write(seg.Value(w.source))
+	}
+	return b.String()
+}
+
+// renderList writes the items of an ordered or unordered list with bullets or numbers.
+func (w *markdownTextWriter) renderList(n *ast.List) {
+	breaks := 2
+	if _, nested := n.Parent().(*ast.ListItem); nested {
+		breaks = 1
+	}
+	w.blockBreak(breaks)
+
+	num := n.Start
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		marker := "* "
+		if n.IsOrdered() {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+
+		w.startListItem(marker)
+		w.renderChildren(c)
+		w.endListItem()
+	}
+
+	w.blockBreak(breaks)
+}
+
+// renderTable writes each row of a GFM table as a line with the text of its cells, separated by " | ".
+func (w *markdownTextWriter) renderTable(n *extast.Table) {
+	var rows []tableRow
+	for r := n.FirstChild(); r != nil; r = r.NextSibling() {
+		var row tableRow
+		for c := r.FirstChild(); c != nil; c = c.NextSibling() {
+			cw := &markdownTextWriter{blockWriter: blockWriter{lineStart: true}, source: w.source, opts: w.opts}
+			cw.renderChildren(c)
+
+			var lines []string
+			if str := strings.Trim(cw.b.String(), "\n"); str != "" {
+				lines = strings.Split(str, "\n")
+			}
+			row.cells = append(row.cells, tableCell{lines: lines, span: 1})
+		}
+		rows = append(rows, row)
+	}
+
+	w.writeLines(tableTextLines(rows, " | "))
+}
+
+// linkURL returns the URL to show after the text of a link, if enabled.
+// Only absolute URLs with a safe scheme are shown, and the URL is left out if it's the same as the text.
+func (w *markdownTextWriter) linkURL(n *ast.Link) string {
+	if !w.opts.LinkURLs {
+		return ""
+	}
+
+	dest := string(n.Destination)
+	u, err := url.Parse(dest)
+	if err != nil || !isTextLinkURL(u) {
+		return ""
+	}
+
+	cw := &markdownTextWriter{source: w.source}
+	cw.renderChildren(n)
+	if label := strings.TrimSpace(cw.b.String()); label == dest || label == u.Opaque {
+		return ""
+	}
+	return dest
+}
+
+// unescapeMarkdown resolves backslash escapes and entities in Markdown text.
+func unescapeMarkdown(source []byte) []byte {
+	return util.ResolveEntityNames(util.ResolveNumericReferences(util.UnescapePunctuations(source)))
+}
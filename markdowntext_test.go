@@ -0,0 +1,76 @@
+package texttools
+
+import "testing"
+
+func TestMarkdownToText(t *testing.T) {
+	samples := []sample{
+		{"# Title\n\nText", "Title\n\nText"},
+		{"Some **bold**, _italic_ and ~~struck~~ text", "Some bold, italic and struck text"},
+		{"A [link](https://example.com) and ![an image](cat.png)", "A link and an image"},
+		{"Use `go test` to test", "Use go test to test"},
+		{`Escaped \* and \_ chars, &amp; &copy; &#8217;`, "Escaped * and _ chars, & © ’"},
+		{"soft\nbreak", "soft break"},
+		{"hard  \nbreak", "hard\nbreak"},
+		{"* one\n* two\n  1. a\n  2. b", "* one\n* two\n  1. a\n  2. b"},
+		{"3. three\n4. four", "3. three\n4. four"},
+		{"- [x] done\n- [ ] todo", "* [x] done\n* [ ] todo"},
+		{"before\n\n> quoted\n> text\n\nafter", "before\n\n> quoted text\n\nafter"},
+		{"```go\nfunc main() {\n\treturn\n}\n```", "func main() {\n\treturn\n}"},
+		{"    indented code", "indented code"},
+		{"a\n\n---\n\nb", "a\n\nb"},
+		{"<div><b>HTML</b> block</div>", "HTML block"},
+		{"Inline <span>HTML</span> and<br>break", "Inline HTML and\nbreak"},
+		{"a <span>x</span> <script>alert(1)</script> b", "a x b"},
+		{"a <STYLE type=\"text/css\">p { color: red }</style> b <svg/> c", "a b c"},
+		{"Unclosed <template>**hidden** text\n\nnext paragraph", "Unclosed\n\nnext paragraph"},
+		{"Bare https://example.com link", "Bare https://example.com link"},
+		{"| A | B |\n|---|---|\n| 1 | 2 |", "A | B\n1 | 2"},
+		{"before\n\n| A | B |\n|---|---|\n| 1 |   |\n| | 4 |\n\nafter", "before\n\nA | B\n1\n4\n\nafter"},
+		{"", ""},
+	}
+
+	for _, sample := range samples {
+		if out := MarkdownToText(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestMarkdownToTextWithOptions(t *testing.T) {
+	samples := []sample{
+		{"A [link](https://example.com)", "A link (https://example.com)"},
+		{"A [relative link](/page)", "A relative link"},
+		{"[https://example.com](https://example.com)", "https://example.com"},
+		{"<https://example.com>", "https://example.com"},
+		{"A [script](javascript:alert(1)) and [data](data:text/html,x)", "A script and data"},
+		{"[mail](mailto:a@example.com)", "mail (mailto:a@example.com)"},
+	}
+
+	for _, sample := range samples {
+		if out := MarkdownToTextWithOptions(sample.in, MarkdownTextOptions{LinkURLs: true}); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestMarkdownToTextShorten(t *testing.T) {
+	in := "**Sample** text with a [link](https://example.com) that is too long"
+	expected := "Sample text with..."
+	if out := Shorten(MarkdownToText(in), 20, "..."); out != expected {
+		t.Errorf("got %q from %q, expected %q", out, in, expected)
+	}
+}
+
+func TestMarkdownToTextShortenTable(t *testing.T) {
+	in := "| Name | Price |\n|---|---|\n| Blue widget | $10 |"
+	expected := "Name | Price Blue..."
+	if out := Shorten(MarkdownToText(in), 20, "..."); out != expected {
+		t.Errorf("got %q from %q, expected %q", out, in, expected)
+	}
+}
+
+func BenchmarkMarkdownToText(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = MarkdownToText("# Title\n\nSome **sample** text with [a link](https://example.com)\n\n* one\n* two")
+	}
+}
@@ -0,0 +1,293 @@
+package texttools
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The max number of times FixMojibake will try to undo a mis-decoding.
+// Text is rarely mangled more than twice, so 3 leaves room for a bit of extra bad luck.
+const maxMojibakePasses = 3
+
+// The badness a repair must remove before it's kept.
+// A single "É™" or "Ä–" is just as likely to be meant as to be mojibake, so it takes more than one of those.
+const minMojibakeImprovement = 2
+
+// The lead chars that UTF-8 turns into when decoded with a single byte encoding, which are almost never
+// followed by a continuation char in real text: Â and Ã (Latin-1 letters and symbols), â (punctuation),
+// Ð and Ñ (Cyrillic), and Ă, which is Ã in CP1258.
+const mojibakeStrongLeads = "ÂÃâÐÑĂ"
+
+// The continuation chars that are also used right after a letter in real text, like "NESCAFÉ™" or "Ä–Z".
+const mojibakeTextSymbols = "\u00a0‘’“”•–—…™¢£¥§©ª«®°±²³µ¶·¹º»¼½¾"
+
+// cp1252 differs from Latin-1 only in the 0x80 - 0x9F range.
+// Undefined positions are left as the C1 control chars, the same way browsers do it.
+var cp1252High = [32]rune{
+	0x20AC, //EURO SIGN
+	0x0081, //UNDEFINED
+	0x201A, //SINGLE LOW-9 QUOTATION MARK
+	0x0192, //LATIN SMALL LETTER F WITH HOOK
+	0x201E, //DOUBLE LOW-9 QUOTATION MARK
+	0x2026, //HORIZONTAL ELLIPSIS
+	0x2020, //DAGGER
+	0x2021, //DOUBLE DAGGER
+	0x02C6, //MODIFIER LETTER CIRCUMFLEX ACCENT
+	0x2030, //PER MILLE SIGN
+	0x0160, //LATIN CAPITAL LETTER S WITH CARON
+	0x2039, //SINGLE LEFT-POINTING ANGLE QUOTATION MARK
+	0x0152, //LATIN CAPITAL LIGATURE OE
+	0x008D, //UNDEFINED
+	0x017D, //LATIN CAPITAL LETTER Z WITH CARON
+	0x008F, //UNDEFINED
+	0x0090, //UNDEFINED
+	0x2018, //LEFT SINGLE QUOTATION MARK
+	0x2019, //RIGHT SINGLE QUOTATION MARK
+	0x201C, //LEFT DOUBLE QUOTATION MARK
+	0x201D, //RIGHT DOUBLE QUOTATION MARK
+	0x2022, //BULLET
+	0x2013, //EN DASH
+	0x2014, //EM DASH
+	0x02DC, //SMALL TILDE
+	0x2122, //TRADE MARK SIGN
+	0x0161, //LATIN SMALL LETTER S WITH CARON
+	0x203A, //SINGLE RIGHT-POINTING ANGLE QUOTATION MARK
+	0x0153, //LATIN SMALL LIGATURE OE
+	0x009D, //UNDEFINED
+	0x017E, //LATIN SMALL LETTER Z WITH CARON
+	0x0178, //LATIN CAPITAL LETTER Y WITH DIAERESIS
+}
+
+// mojibakeCodec is a single byte encoding that UTF-8 text may have been wrongly decoded with.
+type mojibakeCodec struct {
+	name   string
+	encode map[rune]byte
+}
+
+var mojibakeCodecs = []mojibakeCodec{
+	{"cp1252", codepageEncoder(cp1252Table())},
+	{"latin-1", codepageEncoder(latin1Table())},
+	{"cp1258", codepageEncoder(cp1258)},
+}
+
+// latin1Table returns the Latin-1 (ISO-8859-1) code page, which maps every byte to the same code point.
+func latin1Table() (table [256]rune) {
+	for i := range table {
+		table[i] = rune(i)
+	}
+	return
+}
+
+// cp1252Table returns the Windows-1252 code page.
+func cp1252Table() (table [256]rune) {
+	table = latin1Table()
+	copy(table[0x80:0xA0], cp1252High[:])
+	return
+}
+
+// codepageEncoder creates a reverse lookup for the non-ASCII part of a code page.
+// Undefined positions are mapped from their C1 control char.
+func codepageEncoder(table [256]rune) map[rune]byte {
+	enc := make(map[rune]byte, 128)
+	for i := 0x80; i < 256; i++ {
+		r := table[i]
+		if r == 0xFFFD {
+			r = rune(i)
+		}
+		enc[r] = byte(i)
+	}
+	return enc
+}
+
+// FixMojibake tries to repair text that has been decoded with the wrong encoding,
+// e.g. "Ã¦Ã¸Ã¥" -> "æøå" and "â€™" -> "’".
+// It handles UTF-8 that was decoded as CP1252, CP1258 or Latin-1 (also several times over),
+// and CP1252 bytes that ended up in a string without being decoded.
+// Invalid UTF-8 that isn't valid CP1252 either, like a stray 0x81 byte, is returned as is.
+// A repair is only kept if it clearly makes the text look less like mojibake,
+// so text like "NESCAFÉ™" or "SKØ®" is left alone.
+// The returned repairs describe each step taken, e.g. "utf-8 decoded as cp1252".
+func FixMojibake(str string) (fixed string, repairs []string) {
+	fixed = str
+
+	// Invalid UTF-8 means that the bytes were never decoded in the first place
+	if !utf8.ValidString(fixed) {
+		var ok bool
+		if fixed, ok = decodeInvalidUTF8(fixed); !ok {
+			return str, nil
+		}
+		repairs = append(repairs, "invalid utf-8 bytes decoded as cp1252")
+	}
+
+	for pass := 0; pass < maxMojibakePasses; pass++ {
+		// A repair must remove at least minMojibakeImprovement of the badness
+		best, bestBadness, bestName := "", mojibakeBadness(fixed)-minMojibakeImprovement+1, ""
+
+		for _, codec := range mojibakeCodecs {
+			candidate, decoded, kept := undoMisdecoding(fixed, codec.encode)
+			if len(decoded) == 0 {
+				continue
+			}
+			badness := mojibakeBadness(candidate) + unrelatedScriptBadness(decoded, kept)
+			if badness < bestBadness {
+				best, bestBadness, bestName = candidate, badness, codec.name
+			}
+		}
+
+		// Stop when nothing made the text clearly better
+		if bestName == "" {
+			break
+		}
+
+		fixed = best
+		repairs = append(repairs, "utf-8 decoded as "+bestName)
+	}
+
+	return
+}
+
+// decodeInvalidUTF8 decodes all bytes that are not part of a valid UTF-8 sequence as CP1252.
+// It returns false if any of those bytes are undefined in CP1252.
+func decodeInvalidUTF8(str string) (string, bool) {
+	table := cp1252Table()
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for len(str) > 0 {
+		r, size := utf8.DecodeRuneInString(str)
+		if r == utf8.RuneError && size == 1 {
+			// Undefined bytes are left as their C1 control char in the table
+			if r = table[str[0]]; r >= 0x80 && r < 0xA0 {
+				return "", false
+			}
+		}
+		b.WriteRune(r)
+		str = str[size:]
+	}
+
+	return b.String(), true
+}
+
+// undoMisdecoding encodes the text with a single byte encoding and decodes any valid
+// multi-byte UTF-8 sequences in the result.
+// Everything else is kept as is, so mixed text can be fixed as well.
+// The decoded chars and the chars that were kept are returned as well.
+func undoMisdecoding(str string, enc map[rune]byte) (fixed string, decoded, kept []rune) {
+	runes := []rune(str)
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for i := 0; i < len(runes); {
+		// Collect up to a full UTF-8 sequence of encodable bytes
+		var buf [utf8.UTFMax]byte
+		n := 0
+		for n < utf8.UTFMax && i+n < len(runes) {
+			c, ok := encodeRune(runes[i+n], enc)
+			if !ok {
+				break
+			}
+			buf[n] = c
+			n++
+		}
+
+		if n > 1 && buf[0] >= 0xC2 {
+			if r, size := utf8.DecodeRune(buf[:n]); r != utf8.RuneError && size > 1 {
+				b.WriteRune(r)
+				decoded = append(decoded, r)
+				i += size
+				continue
+			}
+		}
+
+		b.WriteRune(runes[i])
+		kept = append(kept, runes[i])
+		i++
+	}
+
+	return b.String(), decoded, kept
+}
+
+// encodeRune returns the byte for a rune in a single byte encoding.
+func encodeRune(r rune, enc map[rune]byte) (byte, bool) {
+	if r < 0x80 {
+		return byte(r), true
+	}
+	c, ok := enc[r]
+	return c, ok
+}
+
+// mojibakeBadness scores how much a text looks like mojibake.
+// It counts C1 control chars and the typical "lead char followed by continuation char" pairs
+// that UTF-8 turns into, when decoded with a single byte encoding.
+// Pairs that are also found in real text count less, see mojibakePairBadness.
+func mojibakeBadness(str string) (badness int) {
+	var before, prev rune
+	for _, r := range str {
+		if r >= 0x80 && r <= 0x9F {
+			badness++
+		}
+		if isMojibakeLead(prev) && isMojibakeContinuation(r) {
+			badness += mojibakePairBadness(before, prev, r)
+		}
+		before, prev = prev, r
+	}
+	return
+}
+
+// mojibakePairBadness scores a lead char followed by a continuation char.
+// The pair scores 1 if the continuation char is a symbol that often follows a letter, like "É™" in "NESCAFÉ™".
+// Otherwise, or if the lead char is rarely followed by any symbol, or it's an upper case letter
+// right after a lower case letter, like "Ä™" in "siÄ™", the pair scores 2.
+func mojibakePairBadness(before, lead, cont rune) int {
+	if strings.ContainsRune(mojibakeStrongLeads, lead) || !strings.ContainsRune(mojibakeTextSymbols, cont) ||
+		unicode.IsLower(before) && unicode.IsUpper(lead) {
+		return 2
+	}
+	return 1
+}
+
+// unrelatedScriptBadness counts the decoded chars in a script that isn't used by any of the kept letters,
+// except for Latin, as e.g. a lone "SKØ®" in Latin text is more likely meant than a mangled Arabic "خ".
+// Text that is mojibake all the way through keeps no letters, so any script is fine.
+func unrelatedScriptBadness(decoded, kept []rune) (badness int) {
+	scripts := map[string]bool{}
+	for _, r := range kept {
+		if r >= 0x80 && (isMojibakeLead(r) || isMojibakeContinuation(r)) {
+			continue
+		}
+		if script := runeScript(r); script != "" {
+			scripts[script] = true
+		}
+	}
+	if len(scripts) == 0 {
+		return 0
+	}
+
+	for _, r := range decoded {
+		if script := runeScript(r); script != "" && script != "Latin" && !scripts[script] {
+			badness++
+		}
+	}
+	return
+}
+
+// isMojibakeLead reports whether r is what the lead byte of a multi-byte UTF-8 sequence
+// looks like in Latin-1 and the Windows code pages.
+func isMojibakeLead(r rune) bool {
+	return (r >= 0xC2 && r <= 0xF4) || r == 0x0102 || r == 0x0103 || r == 0x0110 || r == 0x0111 || r == 0x01A0 || r == 0x01AF
+}
+
+// isMojibakeContinuation reports whether r is what a UTF-8 continuation byte (0x80 - 0xBF)
+// looks like in Latin-1 and the Windows code pages.
+func isMojibakeContinuation(r rune) bool {
+	if r >= 0x80 && r <= 0xBF {
+		return true
+	}
+	for _, c := range cp1252High {
+		if r == c {
+			return true
+		}
+	}
+	return false
+}
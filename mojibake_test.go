@@ -0,0 +1,62 @@
+package texttools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFixMojibake(t *testing.T) {
+	samples := []sample{
+		{"Ã¦Ã¸Ã¥", "æøå"},                                   // UTF-8 as CP1252
+		{"Itâ€™s â€œquotedâ€\u009d", "It’s “quoted”"},       // UTF-8 as CP1252, with undefined byte 0x9D
+		{"Itâ\u0080\u0099s", "It’s"},                        // UTF-8 as Latin-1
+		{"ÃƒÂ¦blegrÃƒÂ¸d", "æblegrød"},                      // Doubly encoded
+		{"RÄƒng", "Răng"},                                   // Vietnamese, UTF-8 as CP1252
+		{"Ă¦Ă¸Ă¥", "æøå"},                                   // UTF-8 as CP1258
+		{"Mixed: æøå and Ã¦Ã¸Ã¥ ✓", "Mixed: æøå and æøå ✓"}, // Only the broken part
+		{"caf\xe9", "café"},                                 // Undecoded CP1252
+		{"caf\xe9 \x81", "caf\xe9 \x81"},                    // Not CP1252 either
+		{"Plain ASCII text", "Plain ASCII text"},
+		{"Already fine: æøå “quoted” ✓", "Already fine: æøå “quoted” ✓"},
+		{"Ð¢ÐµÑ\u0081Ñ‚", "Тест"},            // UTF-8 as CP1252, with undefined byte 0x81
+		{"Ã and Â alone", "Ã and Â alone"},   // Can't be decoded, so leave it alone
+		{"siÄ™ bÄ™dzie", "się będzie"},       // Polish, UTF-8 as CP1252
+		{"Test ÐŸÑ€Ð¸Ð²ÐµÑ‚", "Test Привет"}, // Cyrillic in Latin text
+		{"NESCAFÉ™ Gold", "NESCAFÉ™ Gold"},   // Valid text that looks a bit like mojibake
+		{"SKØ® brand", "SKØ® brand"},
+		{"(Ä–Z)", "(Ä–Z)"},
+		{"", ""},
+	}
+
+	for _, sample := range samples {
+		if out, _ := FixMojibake(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestFixMojibakeRepairs(t *testing.T) {
+	samples := []struct {
+		in      string
+		repairs []string
+	}{
+		{"Ã¦Ã¸Ã¥", []string{"utf-8 decoded as cp1252"}},
+		{"ÃƒÂ¦", []string{"utf-8 decoded as cp1252", "utf-8 decoded as cp1252"}},
+		{"Itâ\u0080\u0099s", []string{"utf-8 decoded as latin-1"}},
+		{"caf\xe9", []string{"invalid utf-8 bytes decoded as cp1252"}},
+		{"stray \x81 byte", nil},
+		{"æøå", nil},
+	}
+
+	for _, sample := range samples {
+		if _, repairs := FixMojibake(sample.in); !reflect.DeepEqual(repairs, sample.repairs) {
+			t.Errorf("got %q from %q, expected %q", repairs, sample.in, sample.repairs)
+		}
+	}
+}
+
+func BenchmarkFixMojibake(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = FixMojibake("Itâ€™s a sample text with Ã¦Ã¸Ã¥ in it")
+	}
+}
@@ -0,0 +1,88 @@
+package texttools
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeNFC normalizes the text to Unicode NFC, so e.g. "e" followed by a combining accent becomes "é".
+func NormalizeNFC(str string) string {
+	return norm.NFC.String(str)
+}
+
+// NormalizeNFD normalizes the text to Unicode NFD, so e.g. "é" becomes "e" followed by a combining accent.
+func NormalizeNFD(str string) string {
+	return norm.NFD.String(str)
+}
+
+// NormalizeNFKC normalizes the text to Unicode NFKC, which is like NFC,
+// but also replaces compatibility chars, like "ﬁ" with "fi" and full width letters with normal letters.
+func NormalizeNFKC(str string) string {
+	return norm.NFKC.String(str)
+}
+
+// NormalizeNFKD normalizes the text to Unicode NFKD, which is like NFD,
+// but also replaces compatibility chars, like "ﬁ" with "fi" and full width letters with normal letters.
+func NormalizeNFKD(str string) string {
+	return norm.NFKD.String(str)
+}
+
+// FoldCase applies full Unicode case folding, which makes texts that only differ in case equal,
+// e.g. "ß" becomes "ss" and both "Σ" and "ς" become "σ".
+// Unlike strings.ToLower, the result is only meant for comparing.
+func FoldCase(str string) string {
+	return cases.Fold().String(str)
+}
+
+// FoldCaseLocale applies full Unicode case folding like FoldCase, but with the rules of a locale, like "tr" or "az-Latn".
+// Only Turkish and Azeri fold differently, as they have a dotted and a dotless i: "I" becomes "ı" and "İ" becomes "i".
+func FoldCaseLocale(str, locale string) string {
+	tag := localeTag(locale)
+	if base, _ := tag.Base(); base.String() == "tr" || base.String() == "az" {
+		str = cases.Lower(tag).String(str)
+	}
+	return cases.Fold().String(str)
+}
+
+// ToLowerLocale converts the text to lower case with the rules of a locale,
+// e.g. the Turkish "I" becomes "ı" and the Lithuanian "Ì" keeps its dot as "i̇̀".
+// Unknown locales use the default Unicode rules.
+func ToLowerLocale(str, locale string) string {
+	return cases.Lower(localeTag(locale)).String(str)
+}
+
+// ToUpperLocale converts the text to upper case with the rules of a locale,
+// e.g. the Turkish "i" becomes "İ" and "ß" becomes "SS".
+// Unknown locales use the default Unicode rules.
+func ToUpperLocale(str, locale string) string {
+	return cases.Upper(localeTag(locale)).String(str)
+}
+
+// NormalizeForCompare normalizes text for comparing, e.g. user input with stored values.
+// The text is NFKC normalized, case folded, stripped of invisible chars, and whitespace is collapsed to single spaces and trimmed.
+func NormalizeForCompare(str string) string {
+	return NormalizeForCompareLocale(str, "")
+}
+
+// NormalizeForCompareLocale normalizes text for comparing like NormalizeForCompare, but folds case with the rules of a locale.
+func NormalizeForCompareLocale(str, locale string) string {
+	str = norm.NFKC.String(str)
+	str = FoldCaseLocale(str, locale)
+	// Folding can make the text unnormalized again, e.g. when a folded char gets a combining mark before it
+	str = norm.NFKC.String(str)
+	str = StripInvisibleChars(str, InvisibleStrict)
+	return strings.Join(strings.Fields(str), " ")
+}
+
+// localeTag parses a locale, like "tr" or "lt-LT".
+// Invalid locales return the undetermined language, which uses the default Unicode rules.
+func localeTag(locale string) language.Tag {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return language.Und
+	}
+	return tag
+}
@@ -0,0 +1,92 @@
+package texttools
+
+import "testing"
+
+func TestNormalizeForms(t *testing.T) {
+	samples := []struct {
+		normalize func(string) string
+		in, out   string
+	}{
+		{NormalizeNFC, "e\u0301", "é"},
+		{NormalizeNFD, "é", "e\u0301"},
+		{NormalizeNFKC, "ﬁ Ａ e\u0301 ½", "fi A é 1⁄2"},
+		{NormalizeNFKD, "ﬁ é", "fi e\u0301"},
+	}
+
+	for _, sample := range samples {
+		if out := sample.normalize(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestFoldCase(t *testing.T) {
+	samples := []sample{
+		{"Straße", "strasse"},
+		{"ΣΊΣΥΦΟΣ σίσυφος", "σίσυφοσ σίσυφοσ"},
+		{"İstanbul ISTANBUL", "i\u0307stanbul istanbul"},
+		{"ﬁle", "file"},
+		{"Hello World", "hello world"},
+	}
+
+	for _, sample := range samples {
+		if out := FoldCase(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestCaseLocale(t *testing.T) {
+	samples := []struct {
+		locale, in, fold, lower, upper string
+	}{
+		{"tr", "İstanbul ISPARTA ılık", "istanbul ısparta ılık", "istanbul ısparta ılık", "İSTANBUL ISPARTA ILIK"},
+		{"az-Latn", "İI", "iı", "iı", "İI"},
+		{"lt", "Ìlgas", "ìlgas", "i\u0307\u0300lgas", "ÌLGAS"},
+		{"en", "İstanbul", "i\u0307stanbul", "i\u0307stanbul", "İSTANBUL"},
+		{"de", "Straße", "strasse", "straße", "STRASSE"},
+		{"not a locale", "ABC", "abc", "abc", "ABC"},
+	}
+
+	for _, sample := range samples {
+		if out := FoldCaseLocale(sample.in, sample.locale); out != sample.fold {
+			t.Errorf("got %q when folding %q with locale %q, expected %q", out, sample.in, sample.locale, sample.fold)
+		}
+		if out := ToLowerLocale(sample.in, sample.locale); out != sample.lower {
+			t.Errorf("got %q when lower casing %q with locale %q, expected %q", out, sample.in, sample.locale, sample.lower)
+		}
+		if out := ToUpperLocale(sample.in, sample.locale); out != sample.upper {
+			t.Errorf("got %q when upper casing %q with locale %q, expected %q", out, sample.in, sample.locale, sample.upper)
+		}
+	}
+}
+
+func TestNormalizeForCompare(t *testing.T) {
+	equal := [][]string{
+		{"Café", "cafe\u0301", "CAFÉ", "  café\t"},
+		{"Straße", "STRASSE", "strasse"},
+		{"ﬁle name", "FILE  NAME", "file name "},
+		{"zero\u200bwidth", "zerowidth"},
+		{"ＡＢＣ", "abc"},
+	}
+
+	for _, group := range equal {
+		first := NormalizeForCompare(group[0])
+		for _, str := range group[1:] {
+			if out := NormalizeForCompare(str); out != first {
+				t.Errorf("got %q from %q and %q from %q, expected them to be equal", first, group[0], out, str)
+			}
+		}
+	}
+
+	if a, b := NormalizeForCompare("cafe"), NormalizeForCompare("café"); a == b {
+		t.Errorf("expected %q and %q to be different", a, b)
+	}
+
+	if a, b := NormalizeForCompareLocale("ISPARTA", "tr"), NormalizeForCompareLocale("ısparta", "tr"); a != b {
+		t.Errorf("got %q and %q, expected them to be equal with Turkish rules", a, b)
+	}
+	if a, b := NormalizeForCompareLocale("İzmir", "tr"), NormalizeForCompareLocale("izmir", "tr"); a != b {
+		t.Errorf("got %q and %q, expected them to be equal with Turkish rules", a, b)
+	}
+}
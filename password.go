@@ -0,0 +1,461 @@
+package texttools
+
+import (
+	"crypto/rand"
+	_ "embed" // For the wordlist
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password errors.
+var (
+	// ErrImpossiblePolicy is returned when no password can fulfill a password policy.
+	ErrImpossiblePolicy = errors.New("texttools: impossible password policy")
+
+	// ErrPolicyTooLarge is returned when a password policy is too long and strict to count the passwords that fulfill it.
+	ErrPolicyTooLarge = errors.New("texttools: password policy too large")
+)
+
+// The number of random passwords tried before counting the passwords that fulfill the policy instead,
+// which is slower, but always finds one if the policy is possible.
+const maxPasswordAttempts = 100
+
+// The max number of states passwordCounter may count, which each take up about 200 bytes.
+// It's enough for e.g. 48 chars with at least 12 of each kind, or 1000 chars with at least 1 of each kind.
+const maxPasswordStates = 1 << 18
+
+// The EFF large wordlist for passphrases, with 7776 words, one for each roll of 5 dice.
+// See https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+// The words of the EFF large wordlist, which are split on first use.
+var effWords = struct {
+	once  sync.Once
+	words []string
+}{}
+
+// PasswordPolicy is the chars and the minimum number of each kind of char in a password.
+type PasswordPolicy struct {
+	Length int
+
+	// The chars of each kind. Kinds without chars are not used.
+	Upper, Lower, Digits, Symbols string
+
+	// The minimum number of chars of each kind.
+	MinUpper, MinLower, MinDigits, MinSymbols int
+
+	// Exclude are chars that are never used, like look-alikes or chars that are hard to type.
+	Exclude string
+
+	// MaxRepeat is the maximum number of times the same char can be used in a row, or 0 for no limit.
+	MaxRepeat int
+}
+
+// PassphraseCase is the capitalization of the words in a passphrase.
+type PassphraseCase int
+
+// The capitalizations.
+const (
+	PassphraseLower  PassphraseCase = iota // All words in lower case
+	PassphraseTitle                        // The first letter of each word in upper case
+	PassphraseUpper                        // All words in upper case
+	PassphraseRandom                       // Each word randomly in lower or title case, which adds 1 bit of entropy per word
+)
+
+// PassphraseOptions is the number of words, separator, capitalization and wordlist of a passphrase.
+type PassphraseOptions struct {
+	Words     int
+	Separator string
+	Case      PassphraseCase
+
+	// Wordlist is the words to pick from, without duplicates. Nil uses the EFF large wordlist.
+	Wordlist []string
+}
+
+// DefaultPasswordPolicy returns a policy for 16 chars with at least 1 upper case letter, lower case letter, digit and symbol,
+// and no char more than 2 times in a row.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		Length:     16,
+		Upper:      "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		Lower:      "abcdefghijklmnopqrstuvwxyz",
+		Digits:     "0123456789",
+		Symbols:    "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+		MinUpper:   1,
+		MinLower:   1,
+		MinDigits:  1,
+		MinSymbols: 1,
+		MaxRepeat:  2,
+	}
+}
+
+// DefaultPassphraseOptions returns options for 6 lower case words from the EFF large wordlist, separated by "-",
+// which gives about 77 bits of entropy.
+func DefaultPassphraseOptions() PassphraseOptions {
+	return PassphraseOptions{Words: 6, Separator: "-"}
+}
+
+// GeneratePassword creates a random password that fulfills the policy, using the crypto rand package.
+// All the passwords that fulfill the policy are equally likely: random passwords of all the allowed chars are
+// tried first, and if the policy is too strict for that, a password is picked among all the ones that fulfill it.
+// A char that is in more than one kind of chars only counts as the first kind.
+// ErrImpossiblePolicy is returned if no password can fulfill the policy,
+// and ErrPolicyTooLarge if the policy is so long and strict that the passwords would take too much memory to count.
+func GeneratePassword(policy PasswordPolicy) (string, error) {
+	upper := passwordChars(policy.Upper, policy.Exclude)
+	lower := passwordChars(policy.Lower, policy.Exclude+upper)
+	digits := passwordChars(policy.Digits, policy.Exclude+upper+lower)
+	symbols := passwordChars(policy.Symbols, policy.Exclude+upper+lower+digits)
+	alphabet := upper + lower + digits + symbols
+
+	if policy.Length <= 0 || alphabet == "" ||
+		policy.MinUpper+policy.MinLower+policy.MinDigits+policy.MinSymbols > policy.Length ||
+		policy.MinUpper > 0 && upper == "" || policy.MinLower > 0 && lower == "" ||
+		policy.MinDigits > 0 && digits == "" || policy.MinSymbols > 0 && symbols == "" ||
+		policy.MaxRepeat > 0 && len([]rune(alphabet)) == 1 && policy.Length > policy.MaxRepeat {
+		return "", ErrImpossiblePolicy
+	}
+
+	counter := &passwordCounter{
+		classes: [4]passwordClass{
+			{[]rune(upper), policy.MinUpper},
+			{[]rune(lower), policy.MinLower},
+			{[]rune(digits), policy.MinDigits},
+			{[]rune(symbols), policy.MinSymbols},
+		},
+		length:    policy.Length,
+		maxRepeat: policy.MaxRepeat,
+		memo:      map[passwordState]*big.Int{},
+	}
+	// This is checked up front, so a policy doesn't only fail when the random passwords are unlucky
+	if counter.states() > maxPasswordStates {
+		return "", ErrPolicyTooLarge
+	}
+
+	for i := 0; i < maxPasswordAttempts; i++ {
+		password, err := defaultRandomGenerator.String(policy.Length, alphabet)
+		if err != nil {
+			return "", err
+		}
+
+		if countChars(password, upper) >= policy.MinUpper && countChars(password, lower) >= policy.MinLower &&
+			countChars(password, digits) >= policy.MinDigits && countChars(password, symbols) >= policy.MinSymbols &&
+			(policy.MaxRepeat <= 0 || maxRepeat(password) <= policy.MaxRepeat) {
+			return password, nil
+		}
+	}
+
+	return counter.generate()
+}
+
+// passwordClass is a kind of chars in a password policy, and the minimum number of them.
+type passwordClass struct {
+	chars []rune
+	min   int
+}
+
+// passwordState is a password being generated: the number of chars so far, the number of chars still needed
+// of each kind, and the kind of the last char and the number of times it has been used in a row.
+type passwordState struct {
+	pos  int
+	need [4]int
+	last int
+	run  int
+}
+
+// passwordStep is a way to add the next char to a password: the state after it,
+// the kind of the char, and the number of chars that can be used.
+// A repeat uses the last char again.
+type passwordStep struct {
+	state   passwordState
+	class   int
+	choices int
+	repeat  bool
+}
+
+// passwordCounter counts the passwords that fulfill a policy, to pick one of them at random.
+// Only the states that can still fulfill the policy are kept in memo.
+type passwordCounter struct {
+	classes   [4]passwordClass
+	length    int
+	maxRepeat int
+	memo      map[passwordState]*big.Int
+	ways      big.Int // Reused for the products in count
+}
+
+// generate picks a random password among all the ones that fulfill the policy.
+// Each char is picked with the probability of the passwords that start with it.
+func (c *passwordCounter) generate() (string, error) {
+	state := passwordState{last: -1}
+	for i, class := range c.classes {
+		if class.min > 0 {
+			state.need[i] = class.min
+		}
+	}
+	if c.count(state).Sign() == 0 {
+		return "", ErrImpossiblePolicy
+	}
+
+	password := make([]rune, 0, c.length)
+	for state.pos < c.length {
+		n, err := rand.Int(defaultRandomGenerator, c.count(state))
+		if err != nil {
+			return "", err
+		}
+
+		var buf [len(c.classes) + 1]passwordStep
+		for _, step := range c.steps(state, buf[:0]) {
+			count := c.count(step.state)
+			ways := new(big.Int).Mul(count, big.NewInt(int64(step.choices)))
+			if n.Cmp(ways) >= 0 {
+				n.Sub(n, ways)
+				continue
+			}
+
+			if step.repeat {
+				password = append(password, password[len(password)-1])
+			} else {
+				// Any char of the kind, except the last char
+				idx := int(new(big.Int).Div(n, count).Int64())
+				for _, r := range c.classes[step.class].chars {
+					if len(password) > 0 && r == password[len(password)-1] {
+						continue
+					}
+					if idx == 0 {
+						password = append(password, r)
+						break
+					}
+					idx--
+				}
+			}
+			state = step.state
+			break
+		}
+	}
+
+	return string(password), nil
+}
+
+// count returns the number of ways to finish a password from the state, so it fulfills the policy.
+// The returned number must not be changed.
+func (c *passwordCounter) count(state passwordState) *big.Int {
+	needed := 0
+	for _, need := range state.need {
+		needed += need
+	}
+	switch {
+	case needed > c.length-state.pos:
+		return new(big.Int)
+	case state.pos == c.length:
+		return big.NewInt(1)
+	}
+
+	if n, ok := c.memo[state]; ok {
+		return n
+	}
+
+	var buf [len(c.classes) + 1]passwordStep
+	n := new(big.Int)
+	for _, step := range c.steps(state, buf[:0]) {
+		count := c.count(step.state)
+		if count.Sign() == 0 {
+			continue
+		}
+		c.ways.SetInt64(int64(step.choices))
+		n.Add(n, c.ways.Mul(&c.ways, count))
+	}
+
+	c.memo[state] = n
+	return n
+}
+
+// states returns the max number of states count may keep in memo: for each position, every number of chars still
+// needed of each kind that can be reached and can still fulfill the policy, times each kind and run of the last char.
+// It's an estimate, so it's kept as a float to not overflow.
+func (c *passwordCounter) states() float64 {
+	// Each position has at least 1 state, which also keeps the slices below small
+	if c.length > maxPasswordStates {
+		return float64(c.length)
+	}
+
+	// The number of ways to need a total of s chars, for each s
+	needs := []float64{1}
+	for _, class := range c.classes {
+		min := class.min
+		if min < 0 {
+			min = 0
+		}
+		next := make([]float64, len(needs)+min)
+		for s, n := range needs {
+			for i := 0; i <= min; i++ {
+				next[s+i] += n
+			}
+		}
+		needs = next
+	}
+	minTotal := len(needs) - 1
+
+	// A position can only be reached with at most pos chars fewer than minTotal needed,
+	// and can only fulfill the policy with at most the remaining chars needed
+	total := 0.0
+	for pos := 0; pos < c.length; pos++ {
+		for s := minTotal - pos; s <= c.length-pos && s <= minTotal; s++ {
+			if s >= 0 {
+				total += needs[s]
+			}
+		}
+	}
+
+	runs := 1
+	if c.maxRepeat > 1 {
+		runs = c.maxRepeat
+	}
+	return total * float64(len(c.classes)*runs)
+}
+
+// steps returns the ways to add the next char to a password: a char of each kind, which is not the last char,
+// or the last char again, if it hasn't been used the max number of times in a row.
+// The steps are appended to steps, which can be a buffer of len(c.classes)+1 steps to not allocate.
+func (c *passwordCounter) steps(state passwordState, steps []passwordStep) []passwordStep {
+	for i, class := range c.classes {
+		choices := len(class.chars)
+		if i == state.last {
+			choices--
+		}
+		if choices > 0 {
+			steps = append(steps, passwordStep{c.next(state, i, 1), i, choices, false})
+		}
+	}
+	if state.last >= 0 && (c.maxRepeat <= 0 || state.run < c.maxRepeat) {
+		steps = append(steps, passwordStep{c.next(state, state.last, state.run+1), state.last, 1, true})
+	}
+	return steps
+}
+
+// next returns the state after adding a char of a kind, which has been used run times in a row.
+func (c *passwordCounter) next(state passwordState, class, run int) passwordState {
+	state.pos++
+	if state.need[class] > 0 {
+		state.need[class]--
+	}
+	state.last = class
+	// The run only matters when there's a max
+	state.run = 1
+	if c.maxRepeat > 0 {
+		state.run = run
+	}
+	return state
+}
+
+// GeneratePassphrase creates a random passphrase of words, like "cinema-snowdrift-unsteady-prism-jovial-reborn",
+// using the crypto rand package.
+// The entropy is the number of bits of randomness in the passphrase, assuming the wordlist and options are known.
+func GeneratePassphrase(opts PassphraseOptions) (passphrase string, entropy float64, err error) {
+	words := opts.Wordlist
+	if words == nil {
+		words = effLargeWords()
+	}
+	if opts.Words <= 0 || len(words) == 0 {
+		return "", 0, ErrImpossiblePolicy
+	}
+
+	picked := make([]string, opts.Words)
+	for i := range picked {
+		idx, err := defaultRandomGenerator.Intn(len(words))
+		if err != nil {
+			return "", 0, err
+		}
+		word := words[idx]
+
+		switch opts.Case {
+		case PassphraseTitle:
+			word = titleWord(word)
+		case PassphraseUpper:
+			word = strings.ToUpper(word)
+		case PassphraseRandom:
+			title, err := defaultRandomGenerator.Intn(2)
+			if err != nil {
+				return "", 0, err
+			}
+			if title == 1 {
+				word = titleWord(word)
+			}
+		}
+		picked[i] = word
+	}
+
+	entropy = float64(opts.Words) * math.Log2(float64(len(words)))
+	if opts.Case == PassphraseRandom {
+		entropy += float64(opts.Words)
+	}
+
+	return strings.Join(picked, opts.Separator), entropy, nil
+}
+
+// effLargeWords returns the words of the EFF large wordlist.
+func effLargeWords() []string {
+	effWords.once.Do(func() {
+		for _, line := range strings.Split(strings.TrimSpace(effLargeWordlist), "\n") {
+			// Each line is the dice roll, a tab and the word
+			if i := strings.IndexByte(line, '\t'); i >= 0 {
+				effWords.words = append(effWords.words, line[i+1:])
+			}
+		}
+	})
+	return effWords.words
+}
+
+// passwordChars returns the chars that are not excluded, without duplicates.
+func passwordChars(chars, exclude string) string {
+	var b strings.Builder
+	for _, r := range chars {
+		if !strings.ContainsRune(exclude, r) && !strings.ContainsRune(b.String(), r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// countChars returns the number of chars in str that are in chars.
+func countChars(str, chars string) (count int) {
+	for _, r := range str {
+		if strings.ContainsRune(chars, r) {
+			count++
+		}
+	}
+	return
+}
+
+// maxRepeat returns the longest run of the same char in str.
+func maxRepeat(str string) (longest int) {
+	var prev rune
+	run := 0
+	for _, r := range str {
+		if r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prev = r
+	}
+	return
+}
+
+// titleWord returns the word with the first letter in upper case.
+func titleWord(word string) string {
+	if word == "" {
+		return word
+	}
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
@@ -0,0 +1,221 @@
+package texttools
+
+import (
+	"math"
+	"math/big"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	policy.Exclude = "0O1lI"
+
+	for i := 0; i < 200; i++ {
+		password, err := GeneratePassword(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 16 {
+			t.Errorf("got length %d from %q, expected 16", len(password), password)
+		}
+		if strings.ContainsAny(password, policy.Exclude) {
+			t.Errorf("got excluded chars in %q", password)
+		}
+		if countChars(password, policy.Upper) < 1 || countChars(password, policy.Lower) < 1 ||
+			countChars(password, policy.Digits) < 1 || countChars(password, policy.Symbols) < 1 {
+			t.Errorf("got %q, expected all kinds of chars", password)
+		}
+		if maxRepeat(password) > 2 {
+			t.Errorf("got %q, expected no char more than 2 times in a row", password)
+		}
+	}
+
+	// A policy that is only fulfilled by few of the random passwords
+	policy = PasswordPolicy{Length: 6, Lower: "ab", Digits: "12", MinLower: 3, MinDigits: 3, MaxRepeat: 1}
+	password, err := GeneratePassword(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countChars(password, "ab") != 3 || countChars(password, "12") != 3 || maxRepeat(password) != 1 {
+		t.Errorf("got %q, expected 3 letters and 3 digits without repeats", password)
+	}
+}
+
+func TestGeneratePasswordStrict(t *testing.T) {
+	// Only about 1 in 200,000 random passwords have 10 digits, so the valid passwords are counted instead
+	policy := DefaultPasswordPolicy()
+	policy.MinUpper, policy.MinLower, policy.MinSymbols, policy.MinDigits = 0, 0, 0, 10
+
+	for i := 0; i < 20; i++ {
+		password, err := GeneratePassword(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 16 || countChars(password, policy.Digits) < 10 || maxRepeat(password) > 2 {
+			t.Errorf("got %q, expected 16 chars with at least 10 digits", password)
+		}
+	}
+}
+
+func TestPasswordCounter(t *testing.T) {
+	// Compare the counted passwords with all the passwords of 5 chars from "aB1"
+	counter := &passwordCounter{
+		classes:   [4]passwordClass{{[]rune("B"), 1}, {[]rune("a"), 0}, {[]rune("1"), 2}, {nil, 0}},
+		length:    5,
+		maxRepeat: 2,
+		memo:      map[passwordState]*big.Int{},
+	}
+
+	valid := map[string]bool{}
+	var all func(password string)
+	all = func(password string) {
+		if len(password) == 5 {
+			if countChars(password, "B") >= 1 && countChars(password, "1") >= 2 && maxRepeat(password) <= 2 {
+				valid[password] = true
+			}
+			return
+		}
+		for _, r := range "aB1" {
+			all(password + string(r))
+		}
+	}
+	all("")
+
+	state := passwordState{need: [4]int{1, 0, 2, 0}, last: -1}
+	if n := counter.count(state); n.Int64() != int64(len(valid)) {
+		t.Errorf("got %d passwords, expected %d", n, len(valid))
+	}
+
+	// All the valid passwords are generated, and nothing else
+	seen := map[string]bool{}
+	for i := 0; i < 2000; i++ {
+		password, err := counter.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !valid[password] {
+			t.Errorf("got invalid password %q", password)
+		}
+		seen[password] = true
+	}
+	if len(seen) != len(valid) {
+		t.Errorf("got %d different passwords, expected %d", len(seen), len(valid))
+	}
+}
+
+func TestGeneratePasswordLarge(t *testing.T) {
+	// Random passwords almost never have 12 of each kind in 48 chars, so the valid passwords are counted
+	policy := DefaultPasswordPolicy()
+	policy.Length = 48
+	policy.MinUpper, policy.MinLower, policy.MinDigits, policy.MinSymbols = 12, 12, 12, 12
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	password, err := GeneratePassword(policy)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countChars(password, policy.Digits) != 12 || countChars(password, policy.Symbols) != 12 || maxRepeat(password) > 2 {
+		t.Errorf("got %q, expected 12 chars of each kind", password)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 100<<20 {
+		t.Errorf("got %d MB allocated, expected at most 100 MB", allocated>>20)
+	}
+
+	// Longer and stricter policies are rejected before counting
+	policy.Length = 64
+	policy.MinUpper, policy.MinLower, policy.MinDigits, policy.MinSymbols = 16, 16, 16, 16
+	if password, err := GeneratePassword(policy); err != ErrPolicyTooLarge {
+		t.Errorf("got %q and error %v from %+v, expected %v", password, err, policy, ErrPolicyTooLarge)
+	}
+}
+
+func TestGeneratePasswordImpossible(t *testing.T) {
+	policies := []PasswordPolicy{
+		{},
+		{Length: 4, Lower: "abc", MinLower: 5},
+		{Length: 4, Lower: "abc", MinDigits: 1},
+		{Length: 4, Lower: "abc", Exclude: "abc"},
+		{Length: 4, Lower: "a", MaxRepeat: 2},
+		{Length: 5, Lower: "a", Digits: "1", MinLower: 4, MaxRepeat: 1},
+	}
+
+	for _, policy := range policies {
+		if password, err := GeneratePassword(policy); err != ErrImpossiblePolicy {
+			t.Errorf("got %q and error %v from %+v, expected %v", password, err, policy, ErrImpossiblePolicy)
+		}
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	words := effLargeWords()
+	if len(words) != 7776 || words[0] != "abacus" || words[7775] != "zoom" {
+		t.Errorf("got %d words from %q to %q, expected 7776 words from abacus to zoom", len(words), words[0], words[len(words)-1])
+	}
+
+	passphrase, entropy, err := GeneratePassphrase(DefaultPassphraseOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	picked := strings.Split(passphrase, "-")
+	if len(picked) != 6 {
+		t.Errorf("got %d words in %q, expected 6", len(picked), passphrase)
+	}
+	for _, word := range picked {
+		if !StringInSlice(word, words) {
+			t.Errorf("got %q in %q, which is not in the wordlist", word, passphrase)
+		}
+	}
+	if math.Abs(entropy-77.55) > 0.01 {
+		t.Errorf("got entropy %f, expected 77.55", entropy)
+	}
+}
+
+func TestGeneratePassphraseOptions(t *testing.T) {
+	wordlist := []string{"one", "two", "three", "four"}
+	samples := []struct {
+		opts    PassphraseOptions
+		entropy float64
+		check   func(word string) bool
+	}{
+		{PassphraseOptions{Words: 3, Separator: " ", Wordlist: wordlist}, 6, func(word string) bool {
+			return StringInSlice(word, wordlist)
+		}},
+		{PassphraseOptions{Words: 3, Separator: " ", Case: PassphraseTitle, Wordlist: wordlist}, 6, func(word string) bool {
+			return unicode.IsUpper(rune(word[0])) && StringInSlice(strings.ToLower(word), wordlist)
+		}},
+		{PassphraseOptions{Words: 3, Separator: " ", Case: PassphraseUpper, Wordlist: wordlist}, 6, func(word string) bool {
+			return StringInSlice(strings.ToLower(word), wordlist) && word == strings.ToUpper(word)
+		}},
+		{PassphraseOptions{Words: 3, Separator: " ", Case: PassphraseRandom, Wordlist: wordlist}, 9, func(word string) bool {
+			return StringInSlice(strings.ToLower(word), wordlist) && word[1:] == strings.ToLower(word[1:])
+		}},
+	}
+
+	for _, sample := range samples {
+		passphrase, entropy, err := GeneratePassphrase(sample.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entropy != sample.entropy {
+			t.Errorf("got entropy %f from %+v, expected %f", entropy, sample.opts, sample.entropy)
+		}
+		words := strings.Split(passphrase, " ")
+		if len(words) != 3 {
+			t.Errorf("got %d words in %q, expected 3", len(words), passphrase)
+		}
+		for _, word := range words {
+			if !sample.check(word) {
+				t.Errorf("got unexpected word %q in %q from %+v", word, passphrase, sample.opts)
+			}
+		}
+	}
+
+	if _, _, err := GeneratePassphrase(PassphraseOptions{Words: 3, Wordlist: []string{}}); err != ErrImpossiblePolicy {
+		t.Errorf("got error %v from an empty wordlist, expected %v", err, ErrImpossiblePolicy)
+	}
+}
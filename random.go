@@ -0,0 +1,234 @@
+package texttools
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/bits"
+	"sync"
+	"unicode/utf8"
+)
+
+// Alphabets for random strings.
+const (
+	AlphabetAlphanumeric = possibleChars
+	AlphabetNumeric      = "0123456789"
+	AlphabetHex          = "0123456789abcdef"
+	AlphabetCrockford    = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"                                 // Crockford's base32, without I, L, O and U
+	AlphabetURLSafe      = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_" // The base64 URL alphabet
+	AlphabetUnambiguous  = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"        // Without the look-alikes 0, O, 1, l and I
+)
+
+// Random string errors.
+var (
+	ErrEmptyAlphabet  = errors.New("texttools: empty alphabet")
+	ErrDuplicateChar  = errors.New("texttools: duplicate char in alphabet")
+	ErrNegativeLength = errors.New("texttools: negative length")
+)
+
+// The number of random bytes a RandomGenerator reads at a time.
+const randomBufferSize = 512
+
+// The generator used by RandomString and RandomStringFrom.
+var defaultRandomGenerator = NewRandomGenerator(nil)
+
+// RandomGenerator creates random strings from random bytes, which are read in bulk and buffered.
+// Chars are picked by masking the random bytes to the fewest bits that can hold an index in the alphabet,
+// and rejecting the indexes that are too large, so every char is equally likely, whatever the size of the alphabet.
+// It's safe for concurrent use.
+type RandomGenerator struct {
+	mu     sync.Mutex
+	r      io.Reader
+	buf    []byte
+	pos, n int
+}
+
+// NewRandomGenerator returns a generator, which reads random bytes from r.
+// A nil r uses the crypto rand package. Deterministic readers can be used in tests.
+func NewRandomGenerator(r io.Reader) *RandomGenerator {
+	if r == nil {
+		r = rand.Reader
+	}
+	return &RandomGenerator{r: r}
+}
+
+// String creates a random string of n chars from the alphabet.
+// The alphabet can contain any chars, not just ASCII, but each only once.
+// Every char is equally likely, as long as the reader is uniformly random.
+func (g *RandomGenerator) String(n int, alphabet string) (string, error) {
+	if n < 0 {
+		return "", ErrNegativeLength
+	}
+	if err := checkAlphabet(alphabet); err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// ASCII alphabets are picked from directly, without converting to runes
+	if len(alphabet) == utf8.RuneCountInString(alphabet) {
+		b := make([]byte, n)
+		for i := range b {
+			idx, err := g.index(len(alphabet))
+			if err != nil {
+				return "", err
+			}
+			b[i] = alphabet[idx]
+		}
+		return string(b), nil
+	}
+
+	chars := []rune(alphabet)
+	b := make([]rune, n)
+	for i := range b {
+		idx, err := g.index(len(chars))
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[idx]
+	}
+	return string(b), nil
+}
+
+// Read fills p with random bytes from the buffer, which makes the generator an io.Reader.
+func (g *RandomGenerator) Read(p []byte) (n int, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for n < len(p) {
+		if err = g.fill(); err != nil {
+			return
+		}
+		c := copy(p[n:], g.buf[g.pos:g.n])
+		g.pos += c
+		n += c
+	}
+	return
+}
+
+// Intn returns a random number in [0, n), where every number is equally likely.
+// It panics if n <= 0.
+func (g *RandomGenerator) Intn(n int) (int, error) {
+	if n <= 0 {
+		panic("texttools: invalid argument to Intn")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.index(n)
+}
+
+// RandomString creates a secure pseudorandom alphanumeric string using the crypto rand package.
+// It panics if the system's secure random source fails.
+func RandomString(n int) (str string) {
+	return RandomStringFrom(n, AlphabetAlphanumeric)
+}
+
+// RandomStringFrom creates a secure pseudorandom string of n chars from the alphabet, using the crypto rand package.
+// The alphabet can contain any chars, not just ASCII, but each only once.
+// It panics if the alphabet is invalid or the system's secure random source fails.
+func RandomStringFrom(n int, alphabet string) string {
+	str, err := defaultRandomGenerator.String(n, alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+// RandomStringWithReader creates a random string of n chars from the alphabet, like RandomStringFrom,
+// but with random bytes from r and errors returned instead of panicking.
+// A nil r uses the crypto rand package. Deterministic readers can be used in tests.
+// Every char is equally likely, as long as r is uniformly random.
+// Random bytes are read in bulk, so more bytes than needed may be read from r.
+// Use a RandomGenerator to create several strings from the same reader.
+func RandomStringWithReader(r io.Reader, n int, alphabet string) (string, error) {
+	return NewRandomGenerator(r).String(n, alphabet)
+}
+
+// index returns a random index below size, using rejection sampling.
+// The lock must be held.
+func (g *RandomGenerator) index(size int) (int, error) {
+	bitLen := bits.Len(uint(size - 1))
+	mask := 1<<uint(bitLen) - 1
+
+	for {
+		idx := 0
+		for i := 0; i < (bitLen+7)/8; i++ {
+			b, err := g.readByte()
+			if err != nil {
+				return 0, err
+			}
+			idx = idx<<8 | int(b)
+		}
+		if idx &= mask; idx < size {
+			return idx, nil
+		}
+	}
+}
+
+// readByte returns the next random byte.
+// The lock must be held.
+func (g *RandomGenerator) readByte() (byte, error) {
+	if err := g.fill(); err != nil {
+		return 0, err
+	}
+
+	b := g.buf[g.pos]
+	g.pos++
+	return b, nil
+}
+
+// fill refills the buffer when it's empty.
+// The lock must be held.
+func (g *RandomGenerator) fill() error {
+	if g.pos < g.n {
+		return nil
+	}
+	if g.buf == nil {
+		g.buf = make([]byte, randomBufferSize)
+	}
+
+	n, err := g.r.Read(g.buf)
+	if n == 0 {
+		if err == nil {
+			err = io.ErrNoProgress
+		}
+		return err
+	}
+	g.pos, g.n = 0, n
+	return nil
+}
+
+// checkAlphabet returns an error if the alphabet is empty, is not valid UTF-8 or has duplicates,
+// which would make some chars more likely than others.
+func checkAlphabet(alphabet string) error {
+	if alphabet == "" {
+		return ErrEmptyAlphabet
+	}
+	// Invalid bytes would be picked on their own, which makes the result invalid UTF-8 as well
+	if !utf8.ValidString(alphabet) {
+		return ErrInvalidUTF8
+	}
+
+	var seenASCII [utf8.RuneSelf]bool
+	var seen map[rune]bool
+	for _, c := range alphabet {
+		if c < utf8.RuneSelf {
+			if seenASCII[c] {
+				return ErrDuplicateChar
+			}
+			seenASCII[c] = true
+			continue
+		}
+		if seen == nil {
+			seen = map[rune]bool{}
+		}
+		if seen[c] {
+			return ErrDuplicateChar
+		}
+		seen[c] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package texttools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRandomStringFrom(t *testing.T) {
+	alphabets := []string{AlphabetAlphanumeric, AlphabetNumeric, AlphabetHex, AlphabetCrockford, AlphabetURLSafe, AlphabetUnambiguous, "æøå"}
+
+	for _, alphabet := range alphabets {
+		str := RandomStringFrom(30, alphabet)
+		if l := utf8.RuneCountInString(str); l != 30 {
+			t.Errorf("got length %d from alphabet %q, expected 30", l, alphabet)
+		}
+		for _, r := range str {
+			if !strings.ContainsRune(alphabet, r) {
+				t.Errorf("got %q in %q, which is not in the alphabet %q", r, str, alphabet)
+			}
+		}
+	}
+
+	if strings.ContainsAny(AlphabetUnambiguous, "0O1lI") {
+		t.Errorf("got look-alikes in %q", AlphabetUnambiguous)
+	}
+}
+
+func TestRandomStringWithReader(t *testing.T) {
+	// With 16 chars, each random byte is masked to 4 bits, which is the index of the char
+	r := bytes.NewReader([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 0xf3})
+	str, err := RandomStringWithReader(r, 17, AlphabetHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if str != "0123456789abcdef3" {
+		t.Errorf("got %q, expected %q", str, "0123456789abcdef3")
+	}
+
+	// Values too large for the alphabet are rejected, and not wrapped around
+	r = bytes.NewReader([]byte{10, 11, 15, 7})
+	if str, _ = RandomStringWithReader(r, 1, AlphabetNumeric); str != "7" {
+		t.Errorf("got %q, expected %q", str, "7")
+	}
+}
+
+func TestRandomStringWithReaderErrors(t *testing.T) {
+	samples := []struct {
+		n        int
+		alphabet string
+		random   []byte
+		err      bool
+	}{
+		{5, AlphabetHex, []byte{1, 2}, true},
+		{5, "", []byte{1, 2, 3, 4, 5}, true},
+		{5, "abca", []byte{1, 2, 3, 4, 5}, true},
+		{5, "ab\xff", []byte{1, 2, 3, 4, 5}, true},
+		{5, "ab\xc3", []byte{1, 2, 3, 4, 5}, true},
+		{-1, AlphabetHex, nil, true},
+		{0, AlphabetHex, nil, false},
+	}
+
+	for _, sample := range samples {
+		str, err := RandomStringWithReader(bytes.NewReader(sample.random), sample.n, sample.alphabet)
+		if (err != nil) != sample.err {
+			t.Errorf("got error %v for length %d from %q, expected error: %t", err, sample.n, sample.alphabet, sample.err)
+		}
+		if err != nil && str != "" {
+			t.Errorf("got %q with error %v, expected an empty string", str, err)
+		}
+	}
+
+	if _, err := RandomStringWithReader(nil, 5, "ab\xff"); err != ErrInvalidUTF8 {
+		t.Errorf("got error %v from an alphabet that is not valid UTF-8, expected %v", err, ErrInvalidUTF8)
+	}
+
+	if _, err := RandomStringWithReader(nil, 5, AlphabetHex); err != nil {
+		t.Errorf("got error %v with nil reader, expected the crypto rand reader", err)
+	}
+}
+
+func TestRandomStringFromPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("got no panic from an empty alphabet")
+		}
+	}()
+	RandomStringFrom(5, "")
+}
+
+func TestRandomGenerator(t *testing.T) {
+	// The generator keeps the bytes it has read, so the strings continue where the last one stopped
+	g := NewRandomGenerator(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6}))
+	for _, expected := range []string{"12", "345"} {
+		str, err := g.String(len(expected), AlphabetNumeric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if str != expected {
+			t.Errorf("got %q, expected %q", str, expected)
+		}
+	}
+
+	// Alphabets with more than 256 chars use 2 bytes per char, masked to the needed bits, so 0xffff becomes 511, which is rejected
+	alphabet := []rune{}
+	for r := rune(0x4e00); r < 0x4e00+300; r++ {
+		alphabet = append(alphabet, r)
+	}
+	g = NewRandomGenerator(bytes.NewReader([]byte{0xff, 0xff, 0x01, 0x2b, 0x00, 0x00}))
+	str, err := g.String(2, string(alphabet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := string([]rune{alphabet[0x12b], alphabet[0]}); str != expected {
+		t.Errorf("got %q, expected %q", str, expected)
+	}
+}
+
+func TestRandomGeneratorConcurrent(t *testing.T) {
+	g := NewRandomGenerator(nil)
+	results := make(chan string)
+	for i := 0; i < 20; i++ {
+		go func() {
+			str, err := g.String(20, AlphabetURLSafe)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- str
+		}()
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		str := <-results
+		if len(str) != 20 || seen[str] {
+			t.Errorf("got %q, expected a unique string of 20 chars", str)
+		}
+		seen[str] = true
+	}
+}
+
+func TestRandomGeneratorIntn(t *testing.T) {
+	g := NewRandomGenerator(bytes.NewReader([]byte{5, 6, 7, 3}))
+	for _, expected := range []int{5, 3} {
+		if n, err := g.Intn(6); err != nil || n != expected {
+			t.Errorf("got %d and error %v, expected %d", n, err, expected)
+		}
+	}
+	if _, err := g.Intn(6); err == nil {
+		t.Errorf("got no error from an empty reader")
+	}
+}
@@ -0,0 +1,108 @@
+package texttools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SanitizeStep is a step in a Sanitizer.
+// Any func that takes and returns a string can be used, like HTMLToText or DecodeHTMLEntities.
+type SanitizeStep func(str string) string
+
+// Sanitizer cleans text by running it through a list of steps, in order.
+type Sanitizer struct {
+	Steps []SanitizeStep
+}
+
+var backslashUnescaper = strings.NewReplacer(`\\`, `\`, `\'`, `'`, `\"`, `"`)
+
+// quoteStraightener replaces the quotes of asciiTypography, so StraightenQuotes and TypographyToASCII agree.
+var quoteStraightener = newQuoteStraightener()
+
+// NewSanitizer returns a Sanitizer with the given steps.
+func NewSanitizer(steps ...SanitizeStep) *Sanitizer {
+	return &Sanitizer{Steps: steps}
+}
+
+// LegacyTextSanitizer returns the Sanitizer used by SanitizeText.
+// It converts HTML to text, replaces ½ with 1/2 and removes backslash escapes of backslashes and quotes.
+func LegacyTextSanitizer() *Sanitizer {
+	return NewSanitizer(
+		HTMLToText,
+		Replace("½", "1/2"),
+		Replace(`\\`, `\`),
+		Replace(`\'`, `'`),
+		Replace(`\"`, `"`),
+	)
+}
+
+// PlainTextSanitizer returns a Sanitizer that converts HTML to clean text.
+// Control chars are removed, the text is NFC normalized and whitespace is normalized.
+func PlainTextSanitizer() *Sanitizer {
+	return NewSanitizer(
+		HTMLToText,
+		RemoveControlChars,
+		NormalizeNFC,
+		NormalizeWhitespace,
+	)
+}
+
+// SearchTextSanitizer returns a Sanitizer that prepares HTML for e.g. a search index.
+// It's like PlainTextSanitizer, but also replaces typographic quotes with straight quotes.
+func SearchTextSanitizer() *Sanitizer {
+	return NewSanitizer(
+		HTMLToText,
+		RemoveControlChars,
+		NormalizeNFC,
+		StraightenQuotes,
+		NormalizeWhitespace,
+	)
+}
+
+// Sanitize runs the text through all the steps of the Sanitizer.
+func (s *Sanitizer) Sanitize(str string) string {
+	for _, step := range s.Steps {
+		str = step(str)
+	}
+	return str
+}
+
+// Replace returns a step that replaces strings, given as old, new pairs like strings.NewReplacer.
+func Replace(oldnew ...string) SanitizeStep {
+	return strings.NewReplacer(oldnew...).Replace
+}
+
+// UnescapeBackslashes removes backslash escapes of backslashes and quotes, e.g. `\'` becomes `'`.
+func UnescapeBackslashes(str string) string {
+	return backslashUnescaper.Replace(str)
+}
+
+// StraightenQuotes replaces typographic quotes, like ‘ ’ “ ” and the guillemets « » ‹ ›, with straight quotes,
+// the same way TypographyToASCII does.
+func StraightenQuotes(str string) string {
+	return quoteStraightener.Replace(str)
+}
+
+// newQuoteStraightener returns a replacer for the chars in asciiTypography that become straight quotes.
+func newQuoteStraightener() *strings.Replacer {
+	var oldnew []string
+	for r, ascii := range asciiTypography {
+		if ascii == "'" || ascii == `"` {
+			oldnew = append(oldnew, string(r), ascii)
+		}
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// RemoveControlChars removes all control chars, except newlines and tabs.
+// Carriage returns are converted to newlines.
+func RemoveControlChars(str string) string {
+	str = strings.Replace(str, "\r\n", "\n", -1)
+	str = strings.Replace(str, "\r", "\n", -1)
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, str)
+}
@@ -0,0 +1,50 @@
+package texttools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizerSteps(t *testing.T) {
+	samples := []struct {
+		step    SanitizeStep
+		in, out string
+	}{
+		{UnescapeBackslashes, `It\'s a \"quote\" and a \\ backslash, \\' and \n`, `It's a "quote" and a \ backslash, \' and \n`},
+		{StraightenQuotes, "‘single’ “double” „low‟ 5′ 6″ «guillemets» ‹single›", `'single' "double" "low" 5' 6" "guillemets" 'single'`},
+		{RemoveControlChars, "a\x00b\x1bc\td\r\ne\rf\u0085g​h", "abc\td\ne\nfg​h"},
+		{NormalizeNFC, "Cafe\u0301", "Caf\u00e9"},
+		{NormalizeWhitespace, "  one \t two  three  \n \n\n\n  four  \n", "one two three\n\nfour"},
+		{Replace("½", "1/2", "¼", "1/4"), "½ and ¼", "1/2 and 1/4"},
+	}
+
+	for _, sample := range samples {
+		if out := sample.step(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestSanitizerPresets(t *testing.T) {
+	in := "<p>It’s  “café” \u00a0time</p>\n\n<p>½ \\'quoted\\'</p>"
+	samples := []struct {
+		sanitizer *Sanitizer
+		out       string
+	}{
+		{LegacyTextSanitizer(), "It’s “café” \u00a0time\n\n1/2 'quoted'"},
+		{PlainTextSanitizer(), "It’s “café” time\n\n½ \\'quoted\\'"},
+		{SearchTextSanitizer(), "It's \"café\" time\n\n½ \\'quoted\\'"},
+		{NewSanitizer(), in},
+		{NewSanitizer(HTMLToText, UnescapeBackslashes, strings.ToUpper), "IT’S “CAFÉ” \u00a0TIME\n\n½ 'QUOTED'"},
+	}
+
+	for _, sample := range samples {
+		if out := sample.sanitizer.Sanitize(in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, in, sample.out)
+		}
+	}
+
+	if out := SanitizeText(in); out != samples[0].out {
+		t.Errorf("got %q from SanitizeText, expected the same as LegacyTextSanitizer: %q", out, samples[0].out)
+	}
+}
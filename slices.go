@@ -0,0 +1,155 @@
+package texttools
+
+import (
+	"sort"
+	"strings"
+)
+
+// StringSet is a set of strings, for fast repeated lookups, e.g. in large allow-lists.
+// The zero value is an empty set, ready to use, and a nil set is an empty set that can be read, but not added to.
+type StringSet struct {
+	items map[string]struct{}
+}
+
+// Contains reports whether v is in the slice.
+func Contains[T comparable](s []T, v T) bool {
+	return Index(s, v) >= 0
+}
+
+// Index returns the index of the first v in the slice, or -1 if it isn't there.
+func Index[T comparable](s []T, v T) int {
+	for i, item := range s {
+		if item == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// ContainsFold reports whether a string is in the slice, ignoring case, like strings.EqualFold.
+func ContainsFold(strs []string, str string) bool {
+	return IndexFold(strs, str) >= 0
+}
+
+// IndexFold returns the index of the first string in the slice that is equal to str, ignoring case,
+// like strings.EqualFold, or -1 if there is none.
+// Only simple case folding is used, where each char folds to one char, so e.g. "ß" doesn't match "SS".
+func IndexFold(strs []string, str string) int {
+	for i, item := range strs {
+		if strings.EqualFold(item, str) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ContainsFoldFull reports whether a string is in the slice, with full Unicode case folding, like FoldCase.
+func ContainsFoldFull(strs []string, str string) bool {
+	return IndexFoldFull(strs, str) >= 0
+}
+
+// IndexFoldFull returns the index of the first string in the slice that is equal to str with full Unicode case folding,
+// like FoldCase, so e.g. "Straße" matches "STRASSE", or -1 if there is none.
+func IndexFoldFull(strs []string, str string) int {
+	folded := FoldCase(str)
+	for i, item := range strs {
+		if FoldCase(item) == folded {
+			return i
+		}
+	}
+	return -1
+}
+
+// NewStringSet returns a set with the strings.
+func NewStringSet(strs ...string) *StringSet {
+	s := &StringSet{items: make(map[string]struct{}, len(strs))}
+	s.Add(strs...)
+	return s
+}
+
+// Add adds the strings to the set.
+func (s *StringSet) Add(strs ...string) {
+	if s.items == nil {
+		s.items = make(map[string]struct{}, len(strs))
+	}
+	for _, str := range strs {
+		s.items[str] = struct{}{}
+	}
+}
+
+// Remove removes the strings from the set.
+func (s *StringSet) Remove(strs ...string) {
+	items := s.set()
+	for _, str := range strs {
+		delete(items, str)
+	}
+}
+
+// Contains reports whether a string is in the set.
+func (s *StringSet) Contains(str string) bool {
+	_, ok := s.set()[str]
+	return ok
+}
+
+// Len returns the number of strings in the set.
+func (s *StringSet) Len() int {
+	return len(s.set())
+}
+
+// set returns the strings in the set, or nil if the set is nil.
+func (s *StringSet) set() map[string]struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.items
+}
+
+// Union returns a new set with the strings that are in either set.
+func (s *StringSet) Union(other *StringSet) *StringSet {
+	union := &StringSet{items: make(map[string]struct{}, s.Len()+other.Len())}
+	for str := range s.set() {
+		union.items[str] = struct{}{}
+	}
+	for str := range other.set() {
+		union.items[str] = struct{}{}
+	}
+	return union
+}
+
+// Intersection returns a new set with the strings that are in both sets.
+func (s *StringSet) Intersection(other *StringSet) *StringSet {
+	// Loop over the smallest set
+	small, large := s, other
+	if large.Len() < small.Len() {
+		small, large = large, small
+	}
+
+	intersection := &StringSet{items: map[string]struct{}{}}
+	for str := range small.set() {
+		if large.Contains(str) {
+			intersection.items[str] = struct{}{}
+		}
+	}
+	return intersection
+}
+
+// Difference returns a new set with the strings that are in this set, but not in the other set.
+func (s *StringSet) Difference(other *StringSet) *StringSet {
+	difference := &StringSet{items: map[string]struct{}{}}
+	for str := range s.set() {
+		if !other.Contains(str) {
+			difference.items[str] = struct{}{}
+		}
+	}
+	return difference
+}
+
+// Sorted returns the strings in the set, sorted, e.g. for iterating in a stable order.
+func (s *StringSet) Sorted() []string {
+	strs := make([]string, 0, s.Len())
+	for str := range s.set() {
+		strs = append(strs, str)
+	}
+	sort.Strings(strs)
+	return strs
+}
@@ -0,0 +1,146 @@
+package texttools
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestContainsIndex(t *testing.T) {
+	strs := []string{"a", "b", "c", "b"}
+	if i := Index(strs, "b"); i != 1 {
+		t.Errorf("got index %d of b, expected 1", i)
+	}
+	if i := Index(strs, "d"); i != -1 || Contains(strs, "d") {
+		t.Errorf("got index %d of d, expected -1", i)
+	}
+	if !Contains([]int{1, 2, 3}, 3) || Contains([]int{}, 0) || Contains([]rune(nil), 'a') {
+		t.Errorf("got wrong result from Contains with ints or runes")
+	}
+}
+
+func TestIndexFold(t *testing.T) {
+	strs := []string{"Go", "Straße", "ΣΊΣΥΦΟΣ", "Ǆ"}
+	samples := []struct {
+		str        string
+		fold, full int
+	}{
+		{"GO", 0, 0},
+		{"go", 0, 0},
+		{"straße", 1, 1},
+		{"STRASSE", -1, 1},
+		{"σίσυφος", 2, 2},
+		{"ǆ", 3, 3},
+		{"rust", -1, -1},
+	}
+
+	for _, sample := range samples {
+		if i := IndexFold(strs, sample.str); i != sample.fold || ContainsFold(strs, sample.str) != (i >= 0) {
+			t.Errorf("got index %d from IndexFold of %q, expected %d", i, sample.str, sample.fold)
+		}
+		if i := IndexFoldFull(strs, sample.str); i != sample.full || ContainsFoldFull(strs, sample.str) != (i >= 0) {
+			t.Errorf("got index %d from IndexFoldFull of %q, expected %d", i, sample.str, sample.full)
+		}
+	}
+}
+
+func TestStringSet(t *testing.T) {
+	a := NewStringSet("one", "two", "three", "two")
+	b := NewStringSet("three", "four")
+
+	samples := []sample{
+		{strings.Join(a.Sorted(), ","), "one,three,two"},
+		{strings.Join(a.Union(b).Sorted(), ","), "four,one,three,two"},
+		{strings.Join(a.Intersection(b).Sorted(), ","), "three"},
+		{strings.Join(b.Intersection(a).Sorted(), ","), "three"},
+		{strings.Join(a.Difference(b).Sorted(), ","), "one,two"},
+		{strings.Join(b.Difference(a).Sorted(), ","), "four"},
+	}
+	for _, sample := range samples {
+		if sample.in != sample.out {
+			t.Errorf("got %q, expected %q", sample.in, sample.out)
+		}
+	}
+
+	if a.Len() != 3 || !a.Contains("two") || a.Contains("four") {
+		t.Errorf("got %q, expected one, three and two", a.Sorted())
+	}
+	a.Remove("two", "five")
+	if a.Len() != 2 || a.Contains("two") {
+		t.Errorf("got %q after removing two, expected one and three", a.Sorted())
+	}
+
+	// The zero value is ready to use
+	var empty StringSet
+	if empty.Contains("one") || empty.Len() != 0 || len(empty.Sorted()) != 0 {
+		t.Errorf("got %q from an empty set", empty.Sorted())
+	}
+	empty.Add("one")
+	if !empty.Contains("one") {
+		t.Errorf("got %q after adding one, expected one", empty.Sorted())
+	}
+
+	// A nil set is an empty set
+	var none *StringSet
+	nilSamples := []sample{
+		{strings.Join(a.Union(nil).Sorted(), ","), "one,three"},
+		{strings.Join(none.Union(a).Sorted(), ","), "one,three"},
+		{strings.Join(a.Intersection(nil).Sorted(), ","), ""},
+		{strings.Join(none.Intersection(a).Sorted(), ","), ""},
+		{strings.Join(a.Difference(nil).Sorted(), ","), "one,three"},
+		{strings.Join(none.Difference(a).Sorted(), ","), ""},
+		{strings.Join(none.Sorted(), ","), ""},
+	}
+	for _, sample := range nilSamples {
+		if sample.in != sample.out {
+			t.Errorf("got %q with a nil set, expected %q", sample.in, sample.out)
+		}
+	}
+	none.Remove("one")
+	if none.Contains("one") || none.Len() != 0 {
+		t.Errorf("got %d strings in a nil set, expected 0", none.Len())
+	}
+}
+
+// An allow-list of 10,000 strings, where the searched string is in the middle
+func benchmarkAllowList() ([]string, string) {
+	strs := make([]string, 10000)
+	for i := range strs {
+		strs[i] = "allowed-" + strconv.Itoa(i)
+	}
+	return strs, strs[len(strs)/2]
+}
+
+func BenchmarkStringInSlice(b *testing.B) {
+	strs, search := benchmarkAllowList()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = StringInSlice(search, strs)
+	}
+}
+
+func BenchmarkContains(b *testing.B) {
+	strs, search := benchmarkAllowList()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = Contains(strs, search)
+	}
+}
+
+func BenchmarkContainsFold(b *testing.B) {
+	strs, search := benchmarkAllowList()
+	search = strings.ToUpper(search)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ContainsFold(strs, search)
+	}
+}
+
+func BenchmarkStringSetContains(b *testing.B) {
+	strs, search := benchmarkAllowList()
+	set := NewStringSet(strs...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = set.Contains(search)
+	}
+}
@@ -0,0 +1,589 @@
+package texttools
+
+import (
+	_ "embed" // For the frequency lists
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Frequency lists of common passwords, English words, first names and surnames, ranked by frequency.
+// They are the lists of zxcvbn-go, which come from an early release of zxcvbn, so they are smaller than the lists
+// the estimation was later tuned for: there are only 7,141 passwords, and there is no list of words from TV and film.
+// The file starts with the copyright notices, in lines starting with "#",
+// and each list starts with a line with ":" and the name of the list.
+//
+//go:embed password_frequency_lists.txt
+var passwordFrequencyLists string
+
+// The ranked dictionaries, which are built on first use.
+var rankedDictionaries = struct {
+	once       sync.Once
+	names      []string
+	ranks      map[string]map[string]int
+	maxWordLen int
+}{}
+
+// Constants of the zxcvbn guess estimation.
+const (
+	bruteforceCardinality           = 10
+	minGuessesBeforeGrowingSequence = 10000
+	minSubmatchGuessesSingleChar    = 10
+	minSubmatchGuessesMultiChar     = 50
+	minYearSpace                    = 20
+	maxStrengthPasswordLength       = 100
+)
+
+// The name of the dictionary of the user inputs.
+const userInputsDictionary = "user_inputs"
+
+var (
+	reStartUpper = regexp.MustCompile(`^\p{Lu}[^\p{Lu}]+$`)
+	reEndUpper   = regexp.MustCompile(`^[^\p{Lu}]+\p{Lu}$`)
+	reAllUpper   = regexp.MustCompile(`^[^\p{Ll}]+$`)
+)
+
+// PasswordStrength is the estimated strength of a password.
+type PasswordStrength struct {
+	// Guesses is the estimated number of guesses needed to crack the password.
+	Guesses      float64
+	GuessesLog10 float64
+
+	// Score is the strength from 0 to 4:
+	// 0 is too guessable (< 10^3 guesses), 1 is very guessable (< 10^6), 2 is somewhat guessable (< 10^8),
+	// 3 is safely unguessable (< 10^10) and 4 is very unguessable.
+	Score int
+
+	// Warning explains what's wrong with the password, and Suggestions how to make it stronger.
+	// Both are empty for strong passwords.
+	Warning     string
+	Suggestions []string
+
+	// Matches are the patterns the password is made of, in order.
+	Matches []PasswordMatch
+}
+
+// PasswordMatch is a pattern found in a password.
+type PasswordMatch struct {
+	// Pattern is "dictionary", "spatial", "repeat", "sequence", "regex", "date" or "bruteforce".
+	Pattern string
+	Token   string
+
+	// Start and End are the byte offsets of the token in the password, where End is after the last byte.
+	Start, End int
+
+	// Guesses is the estimated number of guesses needed to find the token.
+	Guesses float64
+
+	// Dictionary is the name of the dictionary of a dictionary match, like "passwords", "english" or "user_inputs",
+	// and Rank is the rank of the word, where 1 is the most common.
+	Dictionary string
+	Rank       int
+	L33t       bool // Whether the word is written with l33t substitutions, like "p@ssw0rd"
+	Reversed   bool // Whether the word is written backwards
+
+	// The rune indexes of the token, where j is the last rune
+	i, j int
+
+	// Dictionary
+	sub map[rune]rune
+
+	// Spatial
+	graph        string
+	turns        int
+	shiftedCount int
+
+	// Repeat
+	baseToken   string
+	baseGuesses float64
+	repeatCount int
+
+	// Sequence
+	ascending bool
+
+	// Regex and date
+	year      int
+	separator string
+}
+
+// passwordOptimum is the best sequence of matches of some length, which ends at some rune:
+// the last match, the product of the guesses of the matches and the total guesses.
+type passwordOptimum struct {
+	m     PasswordMatch
+	pi, g float64
+}
+
+// EstimatePasswordStrength estimates the strength of a password, like zxcvbn.
+// The password is split into the patterns that are the easiest to guess, like common passwords and words
+// (also with l33t substitutions, like "p@ssw0rd", and reversed), keyboard patterns, repeats, sequences, years and dates.
+// User inputs, like the user's name and email, are penalized like common passwords.
+// Only the first 100 chars of long passwords are checked, as the estimation gets slow for long passwords.
+func EstimatePasswordStrength(password string, userInputs ...string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) > maxStrengthPasswordLength {
+		runes = runes[:maxStrengthPasswordLength]
+	}
+
+	userRanks := userInputRanks(userInputs)
+	matches := omnimatch(runes, userRanks)
+	guesses, sequence := mostGuessableMatchSequence(runes, matches)
+
+	strength := PasswordStrength{
+		Guesses:      guesses,
+		GuessesLog10: math.Log10(guesses),
+		Score:        guessesToScore(guesses),
+		Matches:      sequence,
+	}
+	strength.Warning, strength.Suggestions = passwordFeedback(strength.Score, sequence)
+
+	// Offsets in bytes, instead of runes, from the password itself, as invalid bytes are a single rune each
+	offsets := make([]int, len(runes)+1)
+	for i := range runes {
+		_, size := utf8.DecodeRuneInString(password[offsets[i]:])
+		offsets[i+1] = offsets[i] + size
+	}
+	for i := range strength.Matches {
+		strength.Matches[i].Start = offsets[strength.Matches[i].i]
+		strength.Matches[i].End = offsets[strength.Matches[i].j+1]
+	}
+
+	return strength
+}
+
+// guessesToScore returns the score of a number of guesses.
+// The delta gives some margin for the rounding of the guesses.
+func guessesToScore(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	}
+	return 4
+}
+
+// frequencyDictionaries returns the names of the frequency lists and their ranked words.
+func frequencyDictionaries() ([]string, map[string]map[string]int) {
+	rankedDictionaries.once.Do(func() {
+		rankedDictionaries.ranks = map[string]map[string]int{}
+		var ranks map[string]int
+		for _, line := range strings.Split(strings.TrimSpace(passwordFrequencyLists), "\n") {
+			if ranks == nil && strings.HasPrefix(line, "#") {
+				continue
+			}
+			if strings.HasPrefix(line, ":") {
+				ranks = map[string]int{}
+				rankedDictionaries.names = append(rankedDictionaries.names, line[1:])
+				rankedDictionaries.ranks[line[1:]] = ranks
+				continue
+			}
+			ranks[line] = len(ranks) + 1
+			if n := utf8.RuneCountInString(line); n > rankedDictionaries.maxWordLen {
+				rankedDictionaries.maxWordLen = n
+			}
+		}
+	})
+	return rankedDictionaries.names, rankedDictionaries.ranks
+}
+
+// userInputRanks returns the ranked dictionary of the user inputs, in lower case.
+// The words within each input, like the parts of an email address, are added as well.
+func userInputRanks(userInputs []string) map[string]int {
+	ranks := map[string]int{}
+	add := func(word string) {
+		if _, ok := ranks[word]; !ok && word != "" {
+			ranks[word] = len(ranks) + 1
+		}
+	}
+
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		add(input)
+		for _, word := range strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			add(word)
+		}
+	}
+
+	return ranks
+}
+
+// mostGuessableMatchSequence finds the sequence of non-overlapping matches, which covers the password with the fewest guesses.
+// The gaps between matches are filled with bruteforce matches.
+// A sequence of more matches needs more guesses, as the attacker must guess the number of patterns too.
+func mostGuessableMatchSequence(password []rune, matches []PasswordMatch) (float64, []PasswordMatch) {
+	n := len(password)
+	if n == 0 {
+		return 1, nil
+	}
+
+	matchesByJ := make([][]PasswordMatch, n)
+	for _, m := range matches {
+		matchesByJ[m.j] = append(matchesByJ[m.j], m)
+	}
+	for _, ms := range matchesByJ {
+		sort.SliceStable(ms, func(a, b int) bool { return ms[a].i < ms[b].i })
+	}
+
+	// The best sequences of each length l that end at k
+	optimal := make([]map[int]passwordOptimum, n)
+	for k := range optimal {
+		optimal[k] = map[int]passwordOptimum{}
+	}
+
+	update := func(m PasswordMatch, l int) {
+		k := m.j
+		estimatePasswordGuesses(&m, n)
+		pi := m.Guesses
+		if l > 1 {
+			pi *= optimal[m.i-1][l-1].pi
+		}
+		g := factorial(l) * pi
+		g += math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+
+		// Skip the match if a sequence of the same or fewer matches needs fewer guesses
+		for competingL, competing := range optimal[k] {
+			if competingL <= l && competing.g <= g {
+				return
+			}
+		}
+		optimal[k][l] = passwordOptimum{m, pi, g}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range matchesByJ[k] {
+			if m.i > 0 {
+				for _, l := range sortedKeys(optimal[m.i-1]) {
+					update(m, l+1)
+				}
+			} else {
+				update(m, 1)
+			}
+		}
+
+		// Bruteforce matches, which end at k, but never right after another bruteforce match
+		update(bruteforceMatch(password, 0, k), 1)
+		for i := 1; i <= k; i++ {
+			for _, l := range sortedKeys(optimal[i-1]) {
+				if optimal[i-1][l].m.Pattern != "bruteforce" {
+					update(bruteforceMatch(password, i, k), l+1)
+				}
+			}
+		}
+	}
+
+	// Find the sequence with the fewest guesses, and follow it backwards
+	k := n - 1
+	bestL := 0
+	for _, l := range sortedKeys(optimal[k]) {
+		if bestL == 0 || optimal[k][l].g < optimal[k][bestL].g {
+			bestL = l
+		}
+	}
+	guesses := optimal[k][bestL].g
+
+	sequence := make([]PasswordMatch, bestL)
+	for l := bestL; k >= 0; l-- {
+		m := optimal[k][l].m
+		sequence[l-1] = m
+		k = m.i - 1
+	}
+
+	return guesses, sequence
+}
+
+// estimatePasswordGuesses sets the guesses of a match, if they're not set already.
+// Matches that are only a part of the password need at least a few guesses, as the attacker must guess where they are.
+func estimatePasswordGuesses(m *PasswordMatch, passwordLength int) {
+	if m.Guesses > 0 {
+		return
+	}
+
+	minGuesses := 1.0
+	if m.j-m.i+1 < passwordLength {
+		minGuesses = minSubmatchGuessesMultiChar
+		if m.j == m.i {
+			minGuesses = minSubmatchGuessesSingleChar
+		}
+	}
+
+	var guesses float64
+	switch m.Pattern {
+	case "bruteforce":
+		guesses = bruteforceGuesses(m)
+	case "dictionary":
+		guesses = dictionaryGuesses(m)
+	case "spatial":
+		guesses = spatialGuesses(m)
+	case "repeat":
+		guesses = m.baseGuesses * float64(m.repeatCount)
+	case "sequence":
+		guesses = sequenceGuesses(m)
+	case "regex":
+		guesses = math.Max(float64(abs(m.year-referenceYear())), minYearSpace)
+	case "date":
+		guesses = math.Max(float64(abs(m.year-referenceYear())), minYearSpace) * 365
+		if m.separator != "" {
+			guesses *= 4
+		}
+	}
+
+	m.Guesses = math.Max(guesses, minGuesses)
+}
+
+// bruteforceGuesses returns the guesses of a bruteforce match.
+func bruteforceGuesses(m *PasswordMatch) float64 {
+	length := m.j - m.i + 1
+	guesses := math.Pow(bruteforceCardinality, float64(length))
+	if math.IsInf(guesses, 1) {
+		guesses = math.MaxFloat64
+	}
+
+	// Bruteforce matches should never be preferred over other matches, so they need at least as many guesses
+	minGuesses := float64(minSubmatchGuessesMultiChar + 1)
+	if length == 1 {
+		minGuesses = minSubmatchGuessesSingleChar + 1
+	}
+	return math.Max(guesses, minGuesses)
+}
+
+// dictionaryGuesses returns the guesses of a dictionary match: the rank of the word, times its variations.
+func dictionaryGuesses(m *PasswordMatch) float64 {
+	guesses := float64(m.Rank) * uppercaseVariations(m.Token) * l33tVariations(m)
+	if m.Reversed {
+		guesses *= 2
+	}
+	return guesses
+}
+
+// uppercaseVariations returns the number of ways the word could have been capitalized.
+// Capitalizing the first letter, the last letter or all letters is the most common, and counts as 2.
+func uppercaseVariations(word string) float64 {
+	if strings.ToLower(word) == word {
+		return 1
+	}
+	if reStartUpper.MatchString(word) || reEndUpper.MatchString(word) || reAllUpper.MatchString(word) {
+		return 2
+	}
+
+	upper, lower := 0, 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	return choices(upper, lower)
+}
+
+// l33tVariations returns the number of ways the l33t substitutions could have been made.
+func l33tVariations(m *PasswordMatch) float64 {
+	if !m.L33t {
+		return 1
+	}
+
+	variations := 1.0
+	token := []rune(strings.ToLower(m.Token))
+	for subbed, unsubbed := range m.sub {
+		s, u := 0, 0
+		for _, r := range token {
+			if r == subbed {
+				s++
+			} else if r == unsubbed {
+				u++
+			}
+		}
+		if s == 0 || u == 0 {
+			// Either all or none of the letters are substituted, which counts as 2
+			variations *= 2
+		} else {
+			variations *= choices(s, u)
+		}
+	}
+	return variations
+}
+
+// spatialGuesses returns the guesses of a keyboard pattern, from the number of keys, turns and shifted keys.
+func spatialGuesses(m *PasswordMatch) float64 {
+	graph := keyboardGraphs()[m.graph]
+	startingPositions := float64(len(graph.neighbors))
+	length := m.j - m.i + 1
+
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		possibleTurns := m.turns
+		if i-1 < possibleTurns {
+			possibleTurns = i - 1
+		}
+		for j := 1; j <= possibleTurns; j++ {
+			guesses += binomial(i-1, j-1) * startingPositions * math.Pow(graph.averageDegree, float64(j))
+		}
+	}
+
+	if m.shiftedCount > 0 {
+		unshifted := length - m.shiftedCount
+		if unshifted == 0 {
+			guesses *= 2
+		} else {
+			guesses *= choices(m.shiftedCount, unshifted)
+		}
+	}
+	return guesses
+}
+
+// sequenceGuesses returns the guesses of a sequence, like "abc" or "9753".
+// Sequences that start at an obvious char, like "a" or "1", are the most common.
+func sequenceGuesses(m *PasswordMatch) float64 {
+	first, _ := utf8.DecodeRuneInString(m.Token)
+	base := 26.0
+	switch {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	}
+	if !m.ascending {
+		base *= 2
+	}
+	return base * float64(utf8.RuneCountInString(m.Token))
+}
+
+// choices returns the number of ways to pick between 1 and min(a, b) of a+b things.
+func choices(a, b int) (sum float64) {
+	for i := 1; i <= a && i <= b; i++ {
+		sum += binomial(a+b, i)
+	}
+	return
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	result := 1.0
+	for d := 1; d <= k; d++ {
+		result *= float64(n)
+		result /= float64(d)
+		n--
+	}
+	return result
+}
+
+// factorial returns n!.
+func factorial(n int) float64 {
+	result := 1.0
+	for i := 2; i <= n; i++ {
+		result *= float64(i)
+	}
+	return result
+}
+
+// sortedKeys returns the sequence lengths of the optimums, sorted.
+func sortedKeys(m map[int]passwordOptimum) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// passwordFeedback returns a warning and suggestions for a password with the score and matches.
+func passwordFeedback(score int, sequence []PasswordMatch) (warning string, suggestions []string) {
+	if len(sequence) == 0 {
+		return "", []string{"Use a few words, avoid common phrases.", "No need for symbols, digits, or uppercase letters."}
+	}
+	if score > 2 {
+		return "", nil
+	}
+
+	longest := sequence[0]
+	for _, m := range sequence[1:] {
+		if utf8.RuneCountInString(m.Token) > utf8.RuneCountInString(longest.Token) {
+			longest = m
+		}
+	}
+
+	warning, suggestions = matchFeedback(longest, len(sequence) == 1)
+	return warning, append([]string{"Add another word or two. Uncommon words are better."}, suggestions...)
+}
+
+// matchFeedback returns a warning and suggestions for a match.
+func matchFeedback(m PasswordMatch, isSoleMatch bool) (string, []string) {
+	switch m.Pattern {
+	case "dictionary":
+		return dictionaryFeedback(m, isSoleMatch)
+	case "spatial":
+		if m.turns == 1 {
+			return "Straight rows of keys are easy to guess.", []string{"Use a longer keyboard pattern with more turns."}
+		}
+		return "Short keyboard patterns are easy to guess.", []string{"Use a longer keyboard pattern with more turns."}
+	case "repeat":
+		if utf8.RuneCountInString(m.baseToken) == 1 {
+			return `Repeats like "aaa" are easy to guess.`, []string{"Avoid repeated words and characters."}
+		}
+		return `Repeats like "abcabcabc" are only slightly harder to guess than "abc".`, []string{"Avoid repeated words and characters."}
+	case "sequence":
+		return "Sequences like abc or 6543 are easy to guess.", []string{"Avoid sequences."}
+	case "regex":
+		return "Recent years are easy to guess.", []string{"Avoid recent years.", "Avoid years that are associated with you."}
+	case "date":
+		return "Dates are often easy to guess.", []string{"Avoid dates and years that are associated with you."}
+	}
+	return "", nil
+}
+
+// dictionaryFeedback returns a warning and suggestions for a dictionary match.
+func dictionaryFeedback(m PasswordMatch, isSoleMatch bool) (warning string, suggestions []string) {
+	switch m.Dictionary {
+	case "passwords":
+		switch {
+		case isSoleMatch && !m.L33t && !m.Reversed && m.Rank <= 10:
+			warning = "This is a top-10 common password."
+		case isSoleMatch && !m.L33t && !m.Reversed && m.Rank <= 100:
+			warning = "This is a top-100 common password."
+		case isSoleMatch && !m.L33t && !m.Reversed:
+			warning = "This is a very common password."
+		case math.Log10(m.Guesses) <= 4:
+			warning = "This is similar to a commonly used password."
+		}
+	case "english":
+		if isSoleMatch {
+			warning = "A word by itself is easy to guess."
+		}
+	case "surnames", "male_names", "female_names":
+		if isSoleMatch {
+			warning = "Names and surnames by themselves are easy to guess."
+		} else {
+			warning = "Common names and surnames are easy to guess."
+		}
+	case userInputsDictionary:
+		warning = "Personal information, like your name or email, is easy to guess."
+	}
+
+	switch {
+	case reStartUpper.MatchString(m.Token):
+		suggestions = append(suggestions, "Capitalization doesn't help very much.")
+	case reAllUpper.MatchString(m.Token) && strings.ToLower(m.Token) != m.Token:
+		suggestions = append(suggestions, "All-uppercase is almost as easy to guess as all-lowercase.")
+	}
+	if m.Reversed && utf8.RuneCountInString(m.Token) >= 4 {
+		suggestions = append(suggestions, "Reversed words aren't much harder to guess.")
+	}
+	if m.L33t {
+		suggestions = append(suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much.")
+	}
+	return
+}
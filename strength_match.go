@@ -0,0 +1,637 @@
+package texttools
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The common l33t substitutions, by the letter they replace.
+var l33tTable = map[rune][]rune{
+	'a': {'4', '@'},
+	'b': {'8'},
+	'c': {'(', '{', '[', '<'},
+	'e': {'3'},
+	'g': {'6', '9'},
+	'i': {'1', '!', '|'},
+	'l': {'1', '|', '7'},
+	'o': {'0'},
+	's': {'$', '5'},
+	't': {'+', '7'},
+	'x': {'%'},
+	'z': {'2'},
+}
+
+// Keyboard layouts, where each key has its unshifted and shifted char.
+// Rows are shifted half a key on slanted keyboards, and keys are aligned on keypads.
+const (
+	qwertyLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+
+    qQ wW eE rR tT yY uU iI oO pP [{ ]} \|
+     aA sS dD fF gG hH jJ kK lL ;: '"
+      zZ xX cC vV bB nN mM ,< .> /?
+`
+	dvorakLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) [{ ]}
+    '" ,< .> pP yY fF gG cC rR lL /? =+ \|
+     aA oO eE uU iI dD hH tT nN sS -_
+      ;: qQ jJ kK xX bB mM wW vV zZ
+`
+	keypadLayout = `
+  / * -
+7 8 9 +
+4 5 6
+1 2 3
+  0 .
+`
+)
+
+// The keyboard graphs, which are built on first use.
+var keyboardGraphsOnce = struct {
+	once   sync.Once
+	graphs map[string]keyboardGraph
+}{}
+
+// The date splits of digits without separators, by the number of digits:
+// the indexes where the first and second number end, e.g. 1 and 3 for "1/12/99" in "11299".
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},
+	5: {{1, 3}, {2, 3}},
+	6: {{1, 2}, {2, 4}, {4, 5}},
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}},
+}
+
+// The range of years in dates.
+const (
+	dateMinYear = 1000
+	dateMaxYear = 2050
+)
+
+// keyboardGraph is the neighbors of each char on a keyboard, in a fixed order of directions.
+// Neighbors are the chars of the neighboring key, or "" if there is no key in that direction.
+type keyboardGraph struct {
+	neighbors     map[rune][]string
+	shifted       map[rune]bool
+	averageDegree float64
+}
+
+// omnimatch returns all the matches of all patterns in the password, sorted by position.
+func omnimatch(password []rune, userRanks map[string]int) []PasswordMatch {
+	var matches []PasswordMatch
+	matches = append(matches, dictionaryMatch(password, userRanks)...)
+	matches = append(matches, reverseDictionaryMatch(password, userRanks)...)
+	matches = append(matches, l33tMatch(password, userRanks)...)
+	matches = append(matches, spatialMatch(password)...)
+	matches = append(matches, repeatMatch(password, userRanks)...)
+	matches = append(matches, sequenceMatch(password)...)
+	matches = append(matches, recentYearMatch(password)...)
+	matches = append(matches, dateMatch(password)...)
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].i != matches[b].i {
+			return matches[a].i < matches[b].i
+		}
+		return matches[a].j < matches[b].j
+	})
+	return matches
+}
+
+// bruteforceMatch returns a match of the runes from i to j, which must be guessed one by one.
+func bruteforceMatch(password []rune, i, j int) PasswordMatch {
+	return PasswordMatch{Pattern: "bruteforce", Token: string(password[i : j+1]), i: i, j: j}
+}
+
+// dictionaryMatch returns the words of the frequency lists and user inputs in the password.
+func dictionaryMatch(password []rune, userRanks map[string]int) []PasswordMatch {
+	matches := rankedWordMatch(lowerRunes(password), userRanks)
+	for k := range matches {
+		matches[k].Token = string(password[matches[k].i : matches[k].j+1])
+	}
+	return matches
+}
+
+// reverseDictionaryMatch returns the words that are written backwards in the password.
+func reverseDictionaryMatch(password []rune, userRanks map[string]int) (matches []PasswordMatch) {
+	n := len(password)
+	reversed := make([]rune, n)
+	for i, r := range lowerRunes(password) {
+		reversed[n-1-i] = r
+	}
+
+	for _, m := range rankedWordMatch(reversed, userRanks) {
+		m.i, m.j = n-1-m.j, n-1-m.i
+		m.Token = string(password[m.i : m.j+1])
+		// Palindromes are already found as normal words
+		if string(reversed[n-1-m.j:n-m.i]) == strings.ToLower(m.Token) {
+			continue
+		}
+		m.Reversed = true
+		matches = append(matches, m)
+	}
+	return
+}
+
+// l33tMatch returns the words that are written with l33t substitutions in the password, like "p@ssw0rd".
+func l33tMatch(password []rune, userRanks map[string]int) (matches []PasswordMatch) {
+	lower := lowerRunes(password)
+	seen := map[string]bool{}
+
+	for _, sub := range l33tSubs(lower) {
+		translated := make([]rune, len(lower))
+		for i, r := range lower {
+			if letter, ok := sub[r]; ok {
+				translated[i] = letter
+			} else {
+				translated[i] = r
+			}
+		}
+
+		for _, m := range rankedWordMatch(translated, userRanks) {
+			token := lower[m.i : m.j+1]
+			// Single chars are too common, and words without substitutions are already found
+			if m.i == m.j || string(token) == string(translated[m.i:m.j+1]) {
+				continue
+			}
+
+			m.sub = map[rune]rune{}
+			for _, r := range token {
+				if letter, ok := sub[r]; ok {
+					m.sub[r] = letter
+				}
+			}
+			key := strconv.Itoa(m.i) + ":" + strconv.Itoa(m.j) + ":" + m.Dictionary + ":" + string(translated[m.i:m.j+1])
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			m.Token = string(password[m.i : m.j+1])
+			m.L33t = true
+			matches = append(matches, m)
+		}
+	}
+	return
+}
+
+// l33tSubs returns the possible substitutions of the l33t chars in the password,
+// where each l33t char is replaced by one of the letters it can replace.
+func l33tSubs(password []rune) []map[rune]rune {
+	// The letters each l33t char in the password can replace
+	var l33tChars []rune
+	letters := map[rune][]rune{}
+	for _, letter := range "abcegilostxz" {
+		for _, r := range l33tTable[letter] {
+			if strings.ContainsRune(string(password), r) {
+				if letters[r] == nil {
+					l33tChars = append(l33tChars, r)
+				}
+				letters[r] = append(letters[r], letter)
+			}
+		}
+	}
+	if len(l33tChars) == 0 {
+		return nil
+	}
+
+	subs := []map[rune]rune{{}}
+	for _, r := range l33tChars {
+		var next []map[rune]rune
+		for _, sub := range subs {
+			for _, letter := range letters[r] {
+				extended := map[rune]rune{r: letter}
+				for k, v := range sub {
+					extended[k] = v
+				}
+				next = append(next, extended)
+			}
+		}
+		subs = next
+	}
+	return subs
+}
+
+// rankedWordMatch returns the words of the frequency lists and user inputs in the lower case password, without tokens.
+func rankedWordMatch(lower []rune, userRanks map[string]int) (matches []PasswordMatch) {
+	names, dictionaries := frequencyDictionaries()
+
+	// Longer substrings can't be words
+	maxWordLen := rankedDictionaries.maxWordLen
+	for word := range userRanks {
+		if n := utf8.RuneCountInString(word); n > maxWordLen {
+			maxWordLen = n
+		}
+	}
+
+	for i := range lower {
+		for j := i; j < len(lower) && j-i < maxWordLen; j++ {
+			word := string(lower[i : j+1])
+			for _, name := range names {
+				if rank, ok := dictionaries[name][word]; ok {
+					matches = append(matches, PasswordMatch{Pattern: "dictionary", i: i, j: j, Dictionary: name, Rank: rank})
+				}
+			}
+			if rank, ok := userRanks[word]; ok {
+				matches = append(matches, PasswordMatch{Pattern: "dictionary", i: i, j: j, Dictionary: userInputsDictionary, Rank: rank})
+			}
+		}
+	}
+	return
+}
+
+// spatialMatch returns the keyboard patterns in the password, like "qwerty", "zxcvbn" and "7896321", of at least 3 chars.
+func spatialMatch(password []rune) (matches []PasswordMatch) {
+	graphs := keyboardGraphs()
+	for _, name := range []string{"qwerty", "dvorak", "keypad"} {
+		graph := graphs[name]
+		for i := 0; i < len(password)-1; {
+			j := i + 1
+			lastDirection := -1
+			turns := 0
+			shiftedCount := 0
+			if graph.shifted[password[i]] {
+				shiftedCount = 1
+			}
+
+			for ; j < len(password); j++ {
+				direction := -1
+				for d, neighbor := range graph.neighbors[password[j-1]] {
+					if k := strings.IndexRune(neighbor, password[j]); k >= 0 {
+						direction = d
+						if k > 0 {
+							shiftedCount++
+						}
+						break
+					}
+				}
+				if direction < 0 {
+					break
+				}
+				if direction != lastDirection {
+					turns++
+					lastDirection = direction
+				}
+			}
+
+			if j-i > 2 {
+				matches = append(matches, PasswordMatch{
+					Pattern:      "spatial",
+					Token:        string(password[i:j]),
+					i:            i,
+					j:            j - 1,
+					graph:        name,
+					turns:        turns,
+					shiftedCount: shiftedCount,
+				})
+			}
+			i = j
+		}
+	}
+	return
+}
+
+// repeatMatch returns the repeats in the password, like "aaa" and "abcabcabc".
+// At each position, the repeat that covers the most chars is used, with the shortest base that repeats.
+func repeatMatch(password []rune, userRanks map[string]int) (matches []PasswordMatch) {
+	n := len(password)
+	for i := 0; i < n-1; {
+		bestBase, bestCount := 0, 0
+		for base := 1; i+2*base <= n; base++ {
+			count := 1
+			for i+(count+1)*base <= n && equalRunes(password[i:i+base], password[i+count*base:i+(count+1)*base]) {
+				count++
+			}
+			if count > 1 && base*count > bestBase*bestCount {
+				bestBase, bestCount = base, count
+			}
+		}
+		if bestCount == 0 {
+			i++
+			continue
+		}
+
+		base := password[i : i+bestBase]
+		baseGuesses, _ := mostGuessableMatchSequence(base, omnimatch(base, userRanks))
+		matches = append(matches, PasswordMatch{
+			Pattern:     "repeat",
+			Token:       string(password[i : i+bestBase*bestCount]),
+			i:           i,
+			j:           i + bestBase*bestCount - 1,
+			baseToken:   string(base),
+			baseGuesses: baseGuesses,
+			repeatCount: bestCount,
+		})
+		i += bestBase * bestCount
+	}
+	return
+}
+
+// sequenceMatch returns the sequences in the password, like "abc", "6543" and "aceg",
+// where the chars follow each other with the same step of at most 5.
+// Sequences of 2 chars are only found if the step is 1, like "ab".
+func sequenceMatch(password []rune) (matches []PasswordMatch) {
+	const maxDelta = 5
+	if len(password) < 2 {
+		return
+	}
+
+	update := func(i, j int, delta rune) {
+		if (j-i > 1 || delta == 1 || delta == -1) && delta != 0 && delta >= -maxDelta && delta <= maxDelta {
+			matches = append(matches, PasswordMatch{
+				Pattern:   "sequence",
+				Token:     string(password[i : j+1]),
+				i:         i,
+				j:         j,
+				ascending: delta > 0,
+			})
+		}
+	}
+
+	i := 0
+	lastDelta := password[1] - password[0]
+	for k := 2; k < len(password); k++ {
+		delta := password[k] - password[k-1]
+		if delta == lastDelta {
+			continue
+		}
+		update(i, k-1, lastDelta)
+		i = k - 1
+		lastDelta = delta
+	}
+	update(i, len(password)-1, lastDelta)
+	return
+}
+
+// recentYearMatch returns the recent years in the password, from 1900 to 2049.
+func recentYearMatch(password []rune) (matches []PasswordMatch) {
+	for i := 0; i+4 <= len(password); i++ {
+		token := string(password[i : i+4])
+		year, err := strconv.Atoi(token)
+		if err != nil || !isASCIIDigits(token) || year < 1900 || year >= 2050 {
+			continue
+		}
+		matches = append(matches, PasswordMatch{Pattern: "regex", Token: token, i: i, j: i + 3, year: year})
+		i += 3
+	}
+	return
+}
+
+// dateMatch returns the dates in the password, with or without separators, like "13-12-1999", "1.2.03" and "19991213".
+// Dates within other dates are left out, e.g. "1999" in "13121999".
+func dateMatch(password []rune) (matches []PasswordMatch) {
+	n := len(password)
+
+	// Dates without separators, of 4 to 8 digits
+	for i := 0; i <= n-4; i++ {
+		for j := i + 3; j <= i+7 && j < n; j++ {
+			token := string(password[i : j+1])
+			if !isASCIIDigits(token) {
+				break
+			}
+
+			// The candidate with the year closest to now is used, as people tend to use recent dates
+			best, found := 0, false
+			for _, split := range dateSplits[len(token)] {
+				a, _ := strconv.Atoi(token[:split[0]])
+				b, _ := strconv.Atoi(token[split[0]:split[1]])
+				c, _ := strconv.Atoi(token[split[1]:])
+				if year, ok := intsToYear(a, b, c); ok && (!found || abs(year-referenceYear()) < abs(best-referenceYear())) {
+					best, found = year, true
+				}
+			}
+			if found {
+				matches = append(matches, PasswordMatch{Pattern: "date", Token: token, i: i, j: j, year: best})
+			}
+		}
+	}
+
+	// Dates with the same separator twice, of 6 to 10 chars
+	for i := 0; i <= n-6; i++ {
+		for j := i + 5; j <= i+9 && j < n; j++ {
+			token := string(password[i : j+1])
+			year, separator, ok := separatedDate(token)
+			if ok {
+				matches = append(matches, PasswordMatch{Pattern: "date", Token: token, i: i, j: j, year: year, separator: separator})
+			}
+		}
+	}
+
+	var filtered []PasswordMatch
+	for _, m := range matches {
+		inside := false
+		for _, other := range matches {
+			if (other.i != m.i || other.j != m.j) && other.i <= m.i && other.j >= m.j {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// separatedDate returns the year and separator of a date with the same separator twice, like "13-12-1999" or "1/2/03".
+func separatedDate(token string) (year int, separator string, ok bool) {
+	var parts []int
+	for len(token) > 0 {
+		end := 0
+		for end < len(token) && token[end] >= '0' && token[end] <= '9' {
+			end++
+		}
+		if end == 0 || end > 4 {
+			return 0, "", false
+		}
+		part, _ := strconv.Atoi(token[:end])
+		parts = append(parts, part)
+		token = token[end:]
+
+		if token == "" {
+			break
+		}
+		if !strings.ContainsAny(token[:1], " /\\_.-") || separator != "" && token[:1] != separator {
+			return 0, "", false
+		}
+		separator = token[:1]
+		token = token[1:]
+	}
+
+	// The middle part is the day or month, so it can't have more than 2 digits
+	if len(parts) != 3 || parts[1] > 99 {
+		return 0, "", false
+	}
+	year, ok = intsToYear(parts[0], parts[1], parts[2])
+	return year, separator, ok
+}
+
+// intsToYear returns the year of the date made of 3 ints in any common order, like day, month and year,
+// or false if they aren't a date.
+// Two digit years are mapped to the closest century, so 87 becomes 1987 and 15 becomes 2015.
+func intsToYear(a, b, c int) (int, bool) {
+	ints := []int{a, b, c}
+	if b > 31 || b <= 0 {
+		return 0, false
+	}
+
+	over12, over31, under1 := 0, 0, 0
+	for _, v := range ints {
+		if v > 99 && v < dateMinYear || v > dateMaxYear {
+			return 0, false
+		}
+		if v > 31 {
+			over31++
+		}
+		if v > 12 {
+			over12++
+		}
+		if v <= 0 {
+			under1++
+		}
+	}
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return 0, false
+	}
+
+	// The year is first or last, and if it has 4 digits, the rest must be a day and month
+	splits := [][3]int{{c, a, b}, {a, b, c}}
+	for _, split := range splits {
+		if split[0] >= dateMinYear && split[0] <= dateMaxYear {
+			return split[0], isDayMonth(split[1], split[2])
+		}
+	}
+	for _, split := range splits {
+		if isDayMonth(split[1], split[2]) {
+			year := split[0]
+			switch {
+			case year > 99:
+			case year > 50:
+				year += 1900
+			default:
+				year += 2000
+			}
+			return year, true
+		}
+	}
+	return 0, false
+}
+
+// isDayMonth reports whether 2 ints are a day and a month, in any order.
+func isDayMonth(a, b int) bool {
+	return a >= 1 && a <= 31 && b >= 1 && b <= 12 || b >= 1 && b <= 31 && a >= 1 && a <= 12
+}
+
+// keyboardGraphs returns the graphs of the keyboard layouts.
+func keyboardGraphs() map[string]keyboardGraph {
+	keyboardGraphsOnce.once.Do(func() {
+		keyboardGraphsOnce.graphs = map[string]keyboardGraph{
+			"qwerty": buildKeyboardGraph(qwertyLayout, true),
+			"dvorak": buildKeyboardGraph(dvorakLayout, true),
+			"keypad": buildKeyboardGraph(keypadLayout, false),
+		}
+	})
+	return keyboardGraphsOnce.graphs
+}
+
+// buildKeyboardGraph returns the graph of a keyboard layout.
+// On slanted keyboards, each row is shifted half a key to the right of the row above it, so keys have 6 neighbors.
+// On aligned keyboards, like keypads, keys have 8 neighbors.
+func buildKeyboardGraph(layout string, slanted bool) keyboardGraph {
+	type coord struct{ x, y int }
+	positions := map[coord]string{}
+
+	lines := strings.Split(layout, "\n")
+	keyWidth := len(strings.Fields(layout)[0]) + 1
+	for y, line := range lines {
+		slant := 0
+		if slanted {
+			slant = y - 1
+		}
+		for x := 0; x < len(line); x++ {
+			if line[x] == ' ' {
+				continue
+			}
+			key := line[x:]
+			if k := strings.IndexByte(key, ' '); k >= 0 {
+				key = key[:k]
+			}
+			positions[coord{(x - slant) / keyWidth, y}] = key
+			x += len(key)
+		}
+	}
+
+	graph := keyboardGraph{neighbors: map[rune][]string{}, shifted: map[rune]bool{}}
+	degrees := 0
+	for pos, key := range positions {
+		var adjacent []coord
+		if slanted {
+			adjacent = []coord{{pos.x - 1, pos.y}, {pos.x, pos.y - 1}, {pos.x + 1, pos.y - 1}, {pos.x + 1, pos.y}, {pos.x, pos.y + 1}, {pos.x - 1, pos.y + 1}}
+		} else {
+			adjacent = []coord{{pos.x - 1, pos.y}, {pos.x - 1, pos.y - 1}, {pos.x, pos.y - 1}, {pos.x + 1, pos.y - 1}, {pos.x + 1, pos.y}, {pos.x + 1, pos.y + 1}, {pos.x, pos.y + 1}, {pos.x - 1, pos.y + 1}}
+		}
+
+		neighbors := make([]string, len(adjacent))
+		for d, c := range adjacent {
+			neighbors[d] = positions[c]
+			if neighbors[d] != "" {
+				degrees += len(key)
+			}
+		}
+		for k, r := range key {
+			graph.neighbors[r] = neighbors
+			graph.shifted[r] = k > 0
+		}
+	}
+	graph.averageDegree = float64(degrees) / float64(len(graph.neighbors))
+
+	return graph
+}
+
+// lowerRunes returns the runes in lower case, one for one, so indexes stay the same.
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// equalRunes reports whether a and b are the same runes.
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isASCIIDigits reports whether a string only contains the digits 0-9.
+func isASCIIDigits(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] < '0' || str[i] > '9' {
+			return false
+		}
+	}
+	return str != ""
+}
+
+// referenceYear returns the current year, which dates and years are compared to.
+func referenceYear() int {
+	return time.Now().Year()
+}
+
+// abs returns the absolute value of an int.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,163 @@
+package texttools
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	samples := []struct {
+		password string
+		score    int
+		pattern  string
+		warning  string
+	}{
+		{"password", 0, "dictionary", "This is a top-10 common password."},
+		{"P@ssw0rd", 0, "dictionary", "This is similar to a commonly used password."},
+		{"drowssap", 0, "dictionary", "This is similar to a commonly used password."},
+		{"abcdefg", 0, "sequence", "Sequences like abc or 6543 are easy to guess."},
+		{"aaaaaaa", 0, "repeat", `Repeats like "aaa" are easy to guess.`},
+		{"abcabcabc", 0, "repeat", `Repeats like "abcabcabc" are only slightly harder to guess than "abc".`},
+		{"1999", 0, "regex", "Recent years are easy to guess."},
+		{"13-12-1999", 1, "date", "Dates are often easy to guess."},
+		{"correcthorsebatterystaple", 4, "dictionary", ""},
+		{"kjH7#pq2!Lm9xZ", 4, "bruteforce", ""},
+	}
+
+	for _, sample := range samples {
+		strength := EstimatePasswordStrength(sample.password)
+		if strength.Score != sample.score {
+			t.Errorf("got score %d from %q, expected %d", strength.Score, sample.password, sample.score)
+		}
+		if len(strength.Matches) == 0 || strength.Matches[0].Pattern != sample.pattern {
+			t.Errorf("got matches %+v from %q, expected a %s match first", strength.Matches, sample.password, sample.pattern)
+		}
+		if strength.Warning != sample.warning {
+			t.Errorf("got warning %q from %q, expected %q", strength.Warning, sample.password, sample.warning)
+		}
+		if (strength.Score <= 2) != (len(strength.Suggestions) > 0) {
+			t.Errorf("got suggestions %q from %q with score %d", strength.Suggestions, sample.password, strength.Score)
+		}
+		if math.Abs(strength.GuessesLog10-math.Log10(strength.Guesses)) > 1e-9 {
+			t.Errorf("got log10 %f of %f guesses from %q", strength.GuessesLog10, strength.Guesses, sample.password)
+		}
+	}
+}
+
+func TestEstimatePasswordStrengthMatches(t *testing.T) {
+	strength := EstimatePasswordStrength("P@ssw0rd")
+	if m := strength.Matches[0]; !m.L33t || m.Dictionary != "passwords" || m.Rank != 1 || m.Guesses != 8 {
+		t.Errorf("got %+v from P@ssw0rd, expected the l33t word password with 8 guesses", m)
+	}
+
+	strength = EstimatePasswordStrength("drowssap")
+	if m := strength.Matches[0]; !m.Reversed || m.Rank != 1 || m.Guesses != 2 {
+		t.Errorf("got %+v from drowssap, expected the reversed word password with 2 guesses", m)
+	}
+
+	// Offsets are in bytes
+	strength = EstimatePasswordStrength("æøåcorrecthorse")
+	var tokens []string
+	for _, m := range strength.Matches {
+		tokens = append(tokens, m.Token)
+		if "æøåcorrecthorse"[m.Start:m.End] != m.Token {
+			t.Errorf("got offsets %d-%d for %q, expected the offsets of the token", m.Start, m.End, m.Token)
+		}
+	}
+	if strings.Join(tokens, "|") != "æøå|correct|horse" {
+		t.Errorf("got tokens %q, expected æøå, correct and horse", tokens)
+	}
+
+	// Invalid bytes are a single byte each
+	for _, password := range []string{"\xff\xfe", "ab\xffcorrect\xe2\x82horse"} {
+		strength = EstimatePasswordStrength(password)
+		end := 0
+		for _, m := range strength.Matches {
+			if m.Start != end || m.End < m.Start || m.End > len(password) {
+				t.Errorf("got offsets %d-%d for %q in %q, expected them to follow %d and be within %d bytes", m.Start, m.End, m.Token, password, end, len(password))
+				break
+			}
+			end = m.End
+		}
+		if end != len(password) {
+			t.Errorf("got matches up to byte %d of %q, expected %d", end, password, len(password))
+		}
+	}
+}
+
+func TestEstimatePasswordStrengthUserInputs(t *testing.T) {
+	without := EstimatePasswordStrength("wxqtrebd1987")
+	with := EstimatePasswordStrength("wxqtrebd1987", "Wxqtrebd Jones", "wxqtrebd@example.com")
+	if with.Guesses >= without.Guesses/100 {
+		t.Errorf("got %f guesses with user inputs and %f without, expected far fewer with", with.Guesses, without.Guesses)
+	}
+	if m := with.Matches[0]; m.Dictionary != "user_inputs" || m.Token != "wxqtrebd" {
+		t.Errorf("got %+v, expected the user input wxqtrebd", m)
+	}
+	if with.Warning != "Personal information, like your name or email, is easy to guess." {
+		t.Errorf("got warning %q, expected a warning about personal information", with.Warning)
+	}
+}
+
+func TestEstimatePasswordStrengthEmpty(t *testing.T) {
+	strength := EstimatePasswordStrength("")
+	if strength.Score != 0 || strength.Guesses != 1 || len(strength.Matches) != 0 || len(strength.Suggestions) != 2 {
+		t.Errorf("got %+v from an empty password", strength)
+	}
+}
+
+func TestPasswordMatchers(t *testing.T) {
+	samples := []struct {
+		matcher  func(password []rune) []PasswordMatch
+		password string
+		tokens   string
+	}{
+		{spatialMatch, "xqwertyx", "qwerty"},
+		{spatialMatch, "1qaz2wsx", "1qaz|2wsx"},
+		{spatialMatch, "a7896321", "789|321|789|321|7896321"}, // Rows on qwerty and dvorak, and a pattern on the keypad
+		{spatialMatch, "xAoEuX", "AoEu"},
+		{sequenceMatch, "xabcx9753", "abc|9753"},
+		{sequenceMatch, "ZYXW", "ZYXW"},
+		{sequenceMatch, "ab", "ab"},
+		{sequenceMatch, "ac", ""},
+		{recentYearMatch, "abc1987xyz2015", "1987|2015"},
+		{recentYearMatch, "1850", ""},
+		{dateMatch, "x13121999x", "13121999"},
+		{dateMatch, "1.2.03", "1.2.03"},
+		{dateMatch, "1999/12/13", "1999/12/13"},
+		{dateMatch, "13-12/99", ""},
+		{dateMatch, "00000000", ""},
+	}
+
+	for _, sample := range samples {
+		var tokens []string
+		for _, m := range sample.matcher([]rune(sample.password)) {
+			tokens = append(tokens, m.Token)
+		}
+		if out := strings.Join(tokens, "|"); out != sample.tokens {
+			t.Errorf("got %q from %q, expected %q", out, sample.password, sample.tokens)
+		}
+	}
+}
+
+func TestKeyboardGraphs(t *testing.T) {
+	// The starting positions and average degrees of zxcvbn
+	samples := []struct {
+		name      string
+		positions int
+		degree    float64
+	}{
+		{"qwerty", 94, 4.595744680851064},
+		{"dvorak", 94, 4.595744680851064},
+		{"keypad", 15, 5.066666666666666},
+	}
+
+	for _, sample := range samples {
+		graph := keyboardGraphs()[sample.name]
+		if len(graph.neighbors) != sample.positions || math.Abs(graph.averageDegree-sample.degree) > 1e-9 {
+			t.Errorf("got %d positions and degree %f for %s, expected %d and %f",
+				len(graph.neighbors), graph.averageDegree, sample.name, sample.positions, sample.degree)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package texttools
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TextHTMLOptions controls how TextToHTMLWithOptions renders text.
+type TextHTMLOptions struct {
+	// Autolink turns URLs, domains like example.com and email addresses into links.
+	Autolink bool
+
+	// LinkAttributes are added to all links, e.g. {"rel": "nofollow", "target": "_blank"}.
+	LinkAttributes map[string]string
+}
+
+// Top level domains that are linked without a scheme or www, e.g. example.com.
+// Other domains need a scheme or www, as e.g. "index.js" is more likely a file than a domain.
+const autolinkTLDs = `com|net|org|edu|gov|info|biz|io|dev|app|ai|co|me|tv|eu|uk|us|ca|au|de|dk|se|no|fi|fr|nl|be|es|it|ch|at|pl|jp`
+
+var (
+	reParagraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+	reAutolink       = regexp.MustCompile(`(?i)\b(?:` +
+		`(?:https?|ftp)://[^\s<>"]+|` +
+		`www\.[^\s<>"]+|` +
+		`[a-z0-9._%+-]+@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}\b|` +
+		`(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:` + autolinkTLDs + `)\b(?:[/?#][^\s<>"]*)?` +
+		`)`)
+)
+
+// TextToHTML converts standard text to HTML, which is safe to show as is.
+// The text is escaped, blocks separated by empty lines become paragraphs, other newlines become <br>,
+// and URLs and email addresses are linked.
+func TextToHTML(str string) string {
+	return TextToHTMLWithOptions(str, TextHTMLOptions{Autolink: true})
+}
+
+// TextToHTMLWithOptions converts standard text to HTML like TextToHTML, but with control over links.
+func TextToHTMLWithOptions(str string, opts TextHTMLOptions) string {
+	str = strings.Replace(str, "\r\n", "\n", -1)
+	str = strings.Replace(str, "\r", "\n", -1)
+	str = strings.Trim(str, htmlSpace)
+	if str == "" {
+		return ""
+	}
+
+	attrs := linkAttributes(opts.LinkAttributes)
+
+	var b strings.Builder
+	for i, paragraph := range reParagraphBreak.Split(str, -1) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("<p>")
+		for j, line := range strings.Split(strings.TrimRight(paragraph, htmlSpace), "\n") {
+			if j > 0 {
+				b.WriteString("<br>\n")
+			}
+			if opts.Autolink {
+				b.WriteString(autolink(line, attrs))
+			} else {
+				b.WriteString(html.EscapeString(line))
+			}
+		}
+		b.WriteString("</p>")
+	}
+
+	return b.String()
+}
+
+// autolink escapes a line of text and turns URLs and email addresses into links.
+func autolink(line, attrs string) string {
+	var b strings.Builder
+	last := 0
+
+	for _, m := range reAutolink.FindAllStringIndex(line, -1) {
+		link := trimLinkPunctuation(line[m[0]:m[1]])
+
+		href := link
+		switch lower := strings.ToLower(link); {
+		case strings.Contains(lower, "://"):
+		case strings.Contains(lower, "@"):
+			href = "mailto:" + link
+		default:
+			href = "http://" + link
+		}
+
+		b.WriteString(html.EscapeString(line[last:m[0]]))
+		b.WriteString(`<a href="` + html.EscapeString(href) + `"` + attrs + `>` + html.EscapeString(link) + `</a>`)
+		last = m[0] + len(link)
+	}
+
+	b.WriteString(html.EscapeString(line[last:]))
+	return b.String()
+}
+
+// trimLinkPunctuation removes the punctuation at the end of a link, which is most likely part of the sentence,
+// like the dot in "see example.com.".
+// A closing parenthesis is only removed if it isn't matched by an opening one in the link.
+func trimLinkPunctuation(link string) string {
+	for len(link) > 0 {
+		switch c := link[len(link)-1]; {
+		case strings.IndexByte(`.,:;!?'"*_~`, c) >= 0:
+		case c == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		default:
+			return link
+		}
+		link = link[:len(link)-1]
+	}
+	return link
+}
+
+// linkAttributes returns the attributes ready to be written in a tag, sorted by name.
+func linkAttributes(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(" " + html.EscapeString(key) + `="` + html.EscapeString(attrs[key]) + `"`)
+	}
+	return b.String()
+}
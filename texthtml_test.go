@@ -0,0 +1,44 @@
+package texttools
+
+import "testing"
+
+func TestTextToHTML(t *testing.T) {
+	samples := []sample{
+		{"Hello world", "<p>Hello world</p>"},
+		{"  \n\n  ", ""},
+		{"<b>Tom & Jerry</b> \"quoted\"", "<p>&lt;b&gt;Tom &amp; Jerry&lt;/b&gt; &#34;quoted&#34;</p>"},
+		{"Line one\nLine two", "<p>Line one<br>\nLine two</p>"},
+		{"Para one\r\n\r\nPara two\n  \n\n\nPara three", "<p>Para one</p>\n<p>Para two</p>\n<p>Para three</p>"},
+		{"\n\nIndented\n  text  \n\n", "<p>Indented<br>\n  text</p>"},
+		{"Go to https://example.com/path?a=1&b=2 now", `<p>Go to <a href="https://example.com/path?a=1&amp;b=2">https://example.com/path?a=1&amp;b=2</a> now</p>`},
+		{"see example.com.", `<p>see <a href="http://example.com">example.com</a>.</p>`},
+		{"Visit www.example.org/docs, or not!", `<p>Visit <a href="http://www.example.org/docs">www.example.org/docs</a>, or not!</p>`},
+		{"Wiki (https://en.wikipedia.org/wiki/Go_(language))", `<p>Wiki (<a href="https://en.wikipedia.org/wiki/Go_(language)">https://en.wikipedia.org/wiki/Go_(language)</a>)</p>`},
+		{"Is it https://example.com?", `<p>Is it <a href="https://example.com">https://example.com</a>?</p>`},
+		{"Mail john.doe+test@example.co.uk.", `<p>Mail <a href="mailto:john.doe+test@example.co.uk">john.doe+test@example.co.uk</a>.</p>`},
+		{"\"https://example.com/\"", `<p>&#34;<a href="https://example.com/">https://example.com/</a>&#34;</p>`},
+		{"<https://example.com>", `<p>&lt;<a href="https://example.com">https://example.com</a>&gt;</p>`},
+		{"Run index.js or e.g. 1.5 times", "<p>Run index.js or e.g. 1.5 times</p>"},
+		{"javascript:alert(1)", "<p>javascript:alert(1)</p>"},
+	}
+
+	for _, sample := range samples {
+		if out := TextToHTML(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestTextToHTMLWithOptions(t *testing.T) {
+	opts := TextHTMLOptions{Autolink: true, LinkAttributes: map[string]string{"target": "_blank", "rel": "nofollow noopener"}}
+	in := "See https://example.com"
+	out := `<p>See <a href="https://example.com" rel="nofollow noopener" target="_blank">https://example.com</a></p>`
+	if got := TextToHTMLWithOptions(in, opts); got != out {
+		t.Errorf("got %q from %q, expected %q", got, in, out)
+	}
+
+	out = "<p>See https://example.com</p>"
+	if got := TextToHTMLWithOptions(in, TextHTMLOptions{}); got != out {
+		t.Errorf("got %q from %q, expected %q", got, in, out)
+	}
+}
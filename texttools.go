@@ -1,12 +1,9 @@
 package texttools
 
 import (
-	"crypto/rand"
-	"math/big"
 	"regexp"
 	"strings"
 
-	strip "github.com/grokify/html-strip-tags-go"
 	camelcase "github.com/segmentio/go-camelcase"
 	snakecase "github.com/segmentio/go-snakecase"
 )
@@ -16,7 +13,6 @@ const possibleChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ01234
 
 var (
 	reNonAlphabetAndNumbers  = regexp.MustCompile("[^a-zA-Z0-9-]")
-	reLinesAndChars          = regexp.MustCompile("[\\s\\r\\n]+")
 	reSpaceBeforePunctuation = regexp.MustCompile("\\s+([.,;!?]+)")
 	specialCharsReplacer     = strings.NewReplacer(
 		"À", "A",
@@ -88,8 +84,8 @@ var (
 // If possible, it will try to cut at a non-word char.
 // It will strip newlines and carriage returns.
 func Shorten(str string, length int, appendStr string) (shorter string) {
-	// Replace all line chars with space
-	str = reLinesAndChars.ReplaceAllLiteralString(str, " ")
+	// Replace all line chars and other whitespace with a single space
+	str = CollapseWhitespace(str)
 
 	// Replace all spaces before punctuation chars
 	str = reSpaceBeforePunctuation.ReplaceAllString(str, "$1")
@@ -192,31 +188,24 @@ func PascalCase(str string) string {
 }
 
 // StringInSlice will check if a string is in a slice and return true if it is.
+// Use a StringSet for repeated lookups in large slices.
 func StringInSlice(searchStr string, strs []string) bool {
-	for _, str := range strs {
-		if searchStr == str {
-			return true
-		}
-	}
-	return false
+	return Contains(strs, searchStr)
 }
 
 // HTMLToText converts HTML to standard text.
+// Block elements are separated by newlines, entities are decoded and script, style and head content is dropped.
+// Lists are rendered with bullets or numbers, absolute links as "text (url)", images as their alt text
+// and preformatted text is kept as is.
+// Tables are rendered as aligned columns, no wider than 80 chars.
 func HTMLToText(html string) (text string) {
-	text = strings.Replace(html, "\r\n", " ", -1)
-	text = strip.StripTags(text)
-	text = strings.Trim(text, " ")
-	return
+	return renderHTMLText(html, HTMLTextOptions{MaxWidth: defaultHTMLTextWidth})
 }
 
 // TextSanitizer converts HTML to standard text, but also replaces some special chars and escapings.
+// See LegacyTextSanitizer for the steps, and Sanitizer for building other combinations.
 func SanitizeText(txt string) (newTxt string) {
-	newTxt = HTMLToText(txt)
-	newTxt = strings.Replace(newTxt, "½", "1/2", -1)
-	newTxt = strings.Replace(newTxt, "\\\\", "\\", -1)
-	newTxt = strings.Replace(newTxt, "\\'", "'", -1)
-	newTxt = strings.Replace(newTxt, `\"`, `"`, -1)
-	return
+	return LegacyTextSanitizer().Sanitize(txt)
 }
 
 // CP1258ToUTF8 converts a CP1258 byte array to a UTF-8 string.
@@ -231,20 +220,6 @@ func CP1258ToUTF8(txt []byte) (utf8Txt string) {
 	return
 }
 
-// RandomString creates a secure pseudorandom string using the crypto rand package.
-func RandomString(n int) (str string) {
-	// Note: Counter-intuitively, the max here means < max
-	max := big.NewInt(int64(len(possibleChars)))
-	b := make([]byte, n)
-
-	for i := 0; i < n; i++ {
-		idx, _ := rand.Int(rand.Reader, max)
-		b[i] = possibleChars[idx.Int64()]
-	}
-
-	return string(b)
-}
-
 // cp1258 codepage chars
 var cp1258 = [256]rune{
 	0x0000, //NULL
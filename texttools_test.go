@@ -1,7 +1,9 @@
 package texttools
 
 import (
+	"crypto/rand"
 	"io/ioutil"
+	"math/big"
 	"testing"
 )
 
@@ -352,3 +354,24 @@ func BenchmarkRandomString20(b *testing.B) {
 		_ = RandomString(20)
 	}
 }
+
+func BenchmarkRandomStringParallel20(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = RandomString(20)
+		}
+	})
+}
+
+// The old implementation, which calls rand.Int for each char, for comparison
+func BenchmarkRandomStringBigInt20(b *testing.B) {
+	max := big.NewInt(int64(len(possibleChars)))
+	for i := 0; i < b.N; i++ {
+		str := make([]byte, 20)
+		for n := range str {
+			idx, _ := rand.Int(rand.Reader, max)
+			str[n] = possibleChars[idx.Int64()]
+		}
+		_ = string(str)
+	}
+}
@@ -0,0 +1,204 @@
+package texttools
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// QuoteStyle is the quotes used by SmartTypography.
+type QuoteStyle struct {
+	DoubleOpen, DoubleClose string
+	SingleOpen, SingleClose string
+}
+
+// Quote styles for some common locales.
+// French quotes include non-breaking spaces.
+var (
+	EnglishQuotes = QuoteStyle{"“", "”", "‘", "’"}
+	GermanQuotes  = QuoteStyle{"„", "“", "‚", "‘"}
+	FrenchQuotes  = QuoteStyle{"«\u00a0", "\u00a0»", "‹\u00a0", "\u00a0›"}
+	SwissQuotes   = QuoteStyle{"«", "»", "‹", "›"}
+	DanishQuotes  = QuoteStyle{"»", "«", "›", "‹"}
+	SwedishQuotes = QuoteStyle{"”", "”", "’", "’"}
+	PolishQuotes  = QuoteStyle{"„", "”", "«", "»"}
+)
+
+// The quote styles of languages, by ISO 639-1 code.
+var localeQuoteStyles = map[string]QuoteStyle{
+	"en": EnglishQuotes,
+	"nl": EnglishQuotes,
+	"pt": EnglishQuotes,
+	"tr": EnglishQuotes,
+	"zh": EnglishQuotes,
+	"ko": EnglishQuotes,
+	"de": GermanQuotes,
+	"cs": GermanQuotes,
+	"sk": GermanQuotes,
+	"sl": GermanQuotes,
+	"lt": GermanQuotes,
+	"is": GermanQuotes,
+	"bg": GermanQuotes,
+	"ka": GermanQuotes,
+	"fr": FrenchQuotes,
+	"it": SwissQuotes,
+	"es": SwissQuotes,
+	"ca": SwissQuotes,
+	"ru": SwissQuotes,
+	"uk": SwissQuotes,
+	"el": SwissQuotes,
+	"nb": SwissQuotes,
+	"no": SwissQuotes,
+	"nn": SwissQuotes,
+	"da": DanishQuotes,
+	"hr": DanishQuotes,
+	"sv": SwedishQuotes,
+	"fi": SwedishQuotes,
+	"pl": PolishQuotes,
+	"ro": PolishQuotes,
+	"hu": PolishQuotes,
+}
+
+// Typographic chars and their ASCII replacements.
+var asciiTypography = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '‹': "'", '›': "'",
+	'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`, '«': `"`, '»': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...",
+	'⁄': "/",
+	'™': "(TM)", '℠': "(SM)", '®': "(R)", '©': "(C)",
+}
+
+// LocaleQuoteStyle returns the quote style of a locale, like "de" or "fr-CA".
+// English quotes are returned for unknown locales.
+func LocaleQuoteStyle(locale string) QuoteStyle {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if style, ok := localeQuoteStyles[lang]; ok {
+		return style
+	}
+	return EnglishQuotes
+}
+
+// TypographyToASCII replaces typographic chars with their ASCII counterparts.
+// Curly quotes and guillemets become straight quotes, dashes become "-", "…" becomes "...",
+// vulgar fractions like "½" become "1/2", "™" becomes "(TM)" and non-breaking and other special spaces become normal spaces.
+func TypographyToASCII(str string) string {
+	var b strings.Builder
+	b.Grow(len(str))
+
+	var prev rune
+	for _, r := range str {
+		switch {
+		case asciiTypography[r] != "":
+			b.WriteString(asciiTypography[r])
+		case unicode.Is(unicode.Zs, r):
+			b.WriteByte(' ')
+		case unicode.Is(unicode.No, r) && strings.ContainsRune(norm.NFKD.String(string(r)), '⁄'):
+			// Fractions are separated from a whole number before them, so 1½ becomes "1 1/2" and not "11/2"
+			if unicode.IsDigit(prev) {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strings.Replace(norm.NFKD.String(string(r)), "⁄", "/", -1))
+		default:
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+
+	return b.String()
+}
+
+// SmartTypography replaces straight quotes, "--", "---" and "..." with proper typography, like SmartyPants.
+// Quotes use the given style, but apostrophes, like in "don't" and "'90s", are always ’.
+// "--" becomes an en dash (–) and "---" an em dash (—).
+func SmartTypography(str string, quotes QuoteStyle) string {
+	runes := []rune(str)
+	var b strings.Builder
+	b.Grow(len(str))
+
+	// Whether the last thing written was an opening quote or the start of the text
+	opening := true
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		var prev, next rune
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		open := opening || unicode.IsSpace(prev) || strings.ContainsRune("([{<-–—/", prev)
+		opening = false
+
+		switch r {
+		case '"':
+			if open {
+				b.WriteString(quotes.DoubleOpen)
+				opening = true
+			} else {
+				b.WriteString(quotes.DoubleClose)
+			}
+
+		case '\'':
+			switch {
+			case isWordRune(prev) && isWordRune(next):
+				// Apostrophe within a word, like don't
+				b.WriteRune('’')
+			case open && unicode.IsDigit(next) && !isQuoteClosedInWord(runes[i+1:]):
+				// Apostrophe for left out digits, like '90s
+				b.WriteRune('’')
+			case open:
+				b.WriteString(quotes.SingleOpen)
+				opening = true
+			default:
+				b.WriteString(quotes.SingleClose)
+			}
+
+		case '-', '.':
+			n := 1
+			for i+n < len(runes) && runes[i+n] == r {
+				n++
+			}
+			switch {
+			case r == '-' && n == 2:
+				b.WriteRune('–')
+			case r == '-' && n == 3:
+				b.WriteRune('—')
+			case r == '.' && n == 3:
+				b.WriteRune('…')
+			default:
+				b.WriteString(strings.Repeat(string(r), n))
+			}
+			i += n - 1
+
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// isWordRune reports whether r is a letter or a digit.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isQuoteClosedInWord reports whether a single quote is closed at the end of the word at the start of runes,
+// like in '90', in which case the quote before it is an opening quote and not an apostrophe.
+func isQuoteClosedInWord(runes []rune) bool {
+	for _, r := range runes {
+		if r == '\'' {
+			return true
+		}
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package texttools
+
+import "testing"
+
+func TestTypographyToASCII(t *testing.T) {
+	samples := []sample{
+		{"‘single’ “double” „low“ «guillemets» ‹single›", `'single' "double" "low" "guillemets" 'single'`},
+		{"en–dash em—dash minus−sign non‑breaking", "en-dash em-dash minus-sign non-breaking"},
+		{"Wait…", "Wait..."},
+		{"¼ ½ ¾ ⅓ ⅔ ⅛ ⅞ ⅐ ⅑ ⅒ ↉ 1⁄3", "1/4 1/2 3/4 1/3 2/3 1/8 7/8 1/7 1/9 1/10 0/3 1/3"},
+		{"1½ cups, 2¾", "1 1/2 cups, 2 3/4"},
+		{"Brand™ ® © ℠", "Brand(TM) (R) (C) (SM)"},
+		{"a\u00a0b\u2009c\u202fd\u3000e", "a b c d e"},
+		{"Plain ASCII text", "Plain ASCII text"},
+		{"Ærø ²", "Ærø ²"},
+	}
+
+	for _, sample := range samples {
+		if out := TypographyToASCII(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestStraightenQuotesMatchesTypographyToASCII(t *testing.T) {
+	for r, ascii := range asciiTypography {
+		if ascii != "'" && ascii != `"` {
+			continue
+		}
+		if out := StraightenQuotes(string(r)); out != TypographyToASCII(string(r)) {
+			t.Errorf("got %q from %q, expected %q like TypographyToASCII", out, string(r), ascii)
+		}
+	}
+}
+
+func TestSmartTypography(t *testing.T) {
+	samples := []sample{
+		{`"Hello," she said.`, "“Hello,” she said."},
+		{`He said "it's 'quoted' here"`, "He said “it’s ‘quoted’ here”"},
+		{`The dogs' toys in the '90s, not '90'`, "The dogs’ toys in the ’90s, not ‘90’"},
+		{`("quoted") ["quoted"] '"nested"'`, "(“quoted”) [“quoted”] ‘“nested”’"},
+		{"Pages 10--20---or more", "Pages 10–20—or more"},
+		{"Wait... what.... ---- -", "Wait… what.... ---- -"},
+		{`"Done."`, "“Done.”"},
+		{"No quotes", "No quotes"},
+	}
+
+	for _, sample := range samples {
+		if out := SmartTypography(sample.in, EnglishQuotes); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestSmartTypographyLocale(t *testing.T) {
+	in := `"It's 'good'," he said.`
+	samples := []struct {
+		locale, out string
+	}{
+		{"en-US", "“It’s ‘good’,” he said."},
+		{"de_DE", "„It’s ‚good‘,“ he said."},
+		{"fr", "«\u00a0It’s ‹\u00a0good\u00a0›,\u00a0» he said."},
+		{"da", "»It’s ›good‹,« he said."},
+		{"sv", "”It’s ’good’,” he said."},
+		{"pl", "„It’s «good»,” he said."},
+		{"xx", "“It’s ‘good’,” he said."},
+	}
+
+	for _, sample := range samples {
+		if out := SmartTypography(in, LocaleQuoteStyle(sample.locale)); out != sample.out {
+			t.Errorf("got %q from %q with locale %q, expected %q", out, in, sample.locale, sample.out)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package texttools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a Unicode encoding form.
+type Encoding int
+
+// The supported Unicode encodings.
+// EncodingUnknown means that the encoding should be detected from the BOM.
+const (
+	EncodingUnknown Encoding = iota
+	EncodingUTF8
+	EncodingUTF16LE
+	EncodingUTF16BE
+	EncodingUTF32LE
+	EncodingUTF32BE
+)
+
+// Errors returned when decoding or encoding Unicode text.
+var (
+	ErrUnpairedSurrogate = errors.New("texttools: unpaired surrogate")
+	ErrInvalidCodePoint  = errors.New("texttools: invalid code point")
+	ErrInvalidUTF8       = errors.New("texttools: invalid UTF-8")
+	ErrTruncatedInput    = errors.New("texttools: truncated input")
+	ErrUnknownEncoding   = errors.New("texttools: unknown encoding")
+)
+
+// Byte order marks, longest first, so UTF-32LE is not mistaken for UTF-16LE.
+var boms = []struct {
+	enc Encoding
+	bom []byte
+}{
+	{EncodingUTF32LE, []byte{0xFF, 0xFE, 0x00, 0x00}},
+	{EncodingUTF32BE, []byte{0x00, 0x00, 0xFE, 0xFF}},
+	{EncodingUTF8, []byte{0xEF, 0xBB, 0xBF}},
+	{EncodingUTF16LE, []byte{0xFF, 0xFE}},
+	{EncodingUTF16BE, []byte{0xFE, 0xFF}},
+}
+
+// The size of the chunks read by the streaming decoder.
+const utfChunkSize = 4096
+
+// String returns the common name of the encoding.
+func (enc Encoding) String() string {
+	switch enc {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingUTF32LE:
+		return "UTF-32LE"
+	case EncodingUTF32BE:
+		return "UTF-32BE"
+	}
+	return "unknown"
+}
+
+// unitSize returns the number of bytes in a code unit of the encoding.
+func (enc Encoding) unitSize() int {
+	switch enc {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return 2
+	case EncodingUTF32LE, EncodingUTF32BE:
+		return 4
+	}
+	return 1
+}
+
+// DetectBOM detects the encoding from the byte order mark at the start of b.
+// It returns EncodingUnknown and 0 if there is no BOM.
+func DetectBOM(b []byte) (enc Encoding, bomLen int) {
+	for _, bom := range boms {
+		if len(b) >= len(bom.bom) && string(b[:len(bom.bom)]) == string(bom.bom) {
+			return bom.enc, len(bom.bom)
+		}
+	}
+	return EncodingUnknown, 0
+}
+
+// StripBOM removes a byte order mark from the start of b, if there is one.
+func StripBOM(b []byte) []byte {
+	_, bomLen := DetectBOM(b)
+	return b[bomLen:]
+}
+
+// BOM returns the byte order mark for the encoding.
+func BOM(enc Encoding) []byte {
+	for _, bom := range boms {
+		if bom.enc == enc {
+			return append([]byte(nil), bom.bom...)
+		}
+	}
+	return nil
+}
+
+// UTF16LEToUTF8 converts a UTF-16LE byte array to a UTF-8 string.
+// A leading BOM is removed.
+func UTF16LEToUTF8(txt []byte) (string, error) {
+	return DecodeToUTF8(txt, EncodingUTF16LE)
+}
+
+// UTF16BEToUTF8 converts a UTF-16BE byte array to a UTF-8 string.
+// A leading BOM is removed.
+func UTF16BEToUTF8(txt []byte) (string, error) {
+	return DecodeToUTF8(txt, EncodingUTF16BE)
+}
+
+// UTF32LEToUTF8 converts a UTF-32LE byte array to a UTF-8 string.
+// A leading BOM is removed.
+func UTF32LEToUTF8(txt []byte) (string, error) {
+	return DecodeToUTF8(txt, EncodingUTF32LE)
+}
+
+// UTF32BEToUTF8 converts a UTF-32BE byte array to a UTF-8 string.
+// A leading BOM is removed.
+func UTF32BEToUTF8(txt []byte) (string, error) {
+	return DecodeToUTF8(txt, EncodingUTF32BE)
+}
+
+// UTF8ToUTF16LE converts a UTF-8 string to a UTF-16LE byte array without a BOM.
+func UTF8ToUTF16LE(str string) ([]byte, error) {
+	return EncodeFromUTF8(str, EncodingUTF16LE, false)
+}
+
+// UTF8ToUTF16BE converts a UTF-8 string to a UTF-16BE byte array without a BOM.
+func UTF8ToUTF16BE(str string) ([]byte, error) {
+	return EncodeFromUTF8(str, EncodingUTF16BE, false)
+}
+
+// UTF8ToUTF32LE converts a UTF-8 string to a UTF-32LE byte array without a BOM.
+func UTF8ToUTF32LE(str string) ([]byte, error) {
+	return EncodeFromUTF8(str, EncodingUTF32LE, false)
+}
+
+// UTF8ToUTF32BE converts a UTF-8 string to a UTF-32BE byte array without a BOM.
+func UTF8ToUTF32BE(str string) ([]byte, error) {
+	return EncodeFromUTF8(str, EncodingUTF32BE, false)
+}
+
+// DecodeToUTF8 converts a byte array in the given encoding to a UTF-8 string.
+// If enc is EncodingUnknown, the encoding is detected from the BOM, falling back to UTF-8.
+// A leading BOM is removed.
+func DecodeToUTF8(txt []byte, enc Encoding) (string, error) {
+	txt, enc, offset := skipBOM(txt, enc)
+	out, _, err := decodeUTF(nil, txt, enc, true, offset)
+	return string(out), err
+}
+
+// EncodeFromUTF8 converts a UTF-8 string to a byte array in the given encoding,
+// optionally starting with a BOM.
+func EncodeFromUTF8(str string, enc Encoding, withBOM bool) (out []byte, err error) {
+	if enc == EncodingUnknown {
+		return nil, ErrUnknownEncoding
+	}
+	if withBOM {
+		out = BOM(enc)
+	}
+	out, _, err = encodeUTF(out, []byte(str), enc, true, 0)
+	return
+}
+
+// skipBOM removes a leading BOM and detects the encoding from it, if enc is EncodingUnknown.
+// It also returns the number of bytes skipped.
+func skipBOM(txt []byte, enc Encoding) ([]byte, Encoding, int) {
+	bomEnc, bomLen := DetectBOM(txt)
+	if enc == EncodingUnknown {
+		if bomEnc == EncodingUnknown {
+			return txt, EncodingUTF8, 0
+		}
+		return txt[bomLen:], bomEnc, bomLen
+	}
+	if bom := BOM(enc); len(bom) > 0 && len(txt) >= len(bom) && string(txt[:len(bom)]) == string(bom) {
+		return txt[len(bom):], enc, len(bom)
+	}
+	return txt, enc, 0
+}
+
+// readUnit reads a single code unit from the start of b.
+func readUnit(b []byte, enc Encoding) uint32 {
+	switch enc {
+	case EncodingUTF16LE:
+		return uint32(b[0]) | uint32(b[1])<<8
+	case EncodingUTF16BE:
+		return uint32(b[0])<<8 | uint32(b[1])
+	case EncodingUTF32LE:
+		return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	case EncodingUTF32BE:
+		return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	}
+	return uint32(b[0])
+}
+
+// appendUnit appends a single code unit to dst.
+func appendUnit(dst []byte, unit uint32, enc Encoding) []byte {
+	switch enc {
+	case EncodingUTF16LE:
+		return append(dst, byte(unit), byte(unit>>8))
+	case EncodingUTF16BE:
+		return append(dst, byte(unit>>8), byte(unit))
+	case EncodingUTF32LE:
+		return append(dst, byte(unit), byte(unit>>8), byte(unit>>16), byte(unit>>24))
+	case EncodingUTF32BE:
+		return append(dst, byte(unit>>24), byte(unit>>16), byte(unit>>8), byte(unit))
+	}
+	return append(dst, byte(unit))
+}
+
+// decodeUTF decodes src in the given encoding and appends the UTF-8 result to dst.
+// If final is false, an incomplete sequence at the end of src is left for the next call.
+// offset is the position of src in the whole input and is only used for error messages.
+func decodeUTF(dst, src []byte, enc Encoding, final bool, offset int) (out []byte, consumed int, err error) {
+	out = dst
+	size := enc.unitSize()
+
+	for consumed < len(src) {
+		rest := src[consumed:]
+
+		if enc == EncodingUTF8 {
+			if !utf8.FullRune(rest) && !final {
+				return
+			}
+			r, n := utf8.DecodeRune(rest)
+			if r == utf8.RuneError && n <= 1 {
+				if !utf8.FullRune(rest) {
+					return out, consumed, fmt.Errorf("%w at byte %d", ErrTruncatedInput, offset+consumed)
+				}
+				return out, consumed, fmt.Errorf("%w at byte %d", ErrInvalidUTF8, offset+consumed)
+			}
+			out = append(out, rest[:n]...)
+			consumed += n
+			continue
+		}
+
+		if len(rest) < size {
+			if !final {
+				return
+			}
+			return out, consumed, fmt.Errorf("%w at byte %d", ErrTruncatedInput, offset+consumed)
+		}
+
+		unit := readUnit(rest, enc)
+		n := size
+		r := rune(unit)
+
+		switch {
+		case size == 4 && (unit > utf8.MaxRune || utf16.IsSurrogate(rune(unit))):
+			return out, consumed, fmt.Errorf("%w U+%04X at byte %d", ErrInvalidCodePoint, unit, offset+consumed)
+
+		case size == 2 && unit >= 0xD800 && unit < 0xDC00:
+			// A high surrogate must be followed by a low surrogate
+			if len(rest) < 2*size {
+				if !final {
+					return
+				}
+				return out, consumed, fmt.Errorf("%w at byte %d", ErrUnpairedSurrogate, offset+consumed)
+			}
+			low := readUnit(rest[size:], enc)
+			if low < 0xDC00 || low > 0xDFFF {
+				return out, consumed, fmt.Errorf("%w at byte %d", ErrUnpairedSurrogate, offset+consumed)
+			}
+			r = utf16.DecodeRune(rune(unit), rune(low))
+			n = 2 * size
+
+		case size == 2 && unit >= 0xDC00 && unit <= 0xDFFF:
+			return out, consumed, fmt.Errorf("%w at byte %d", ErrUnpairedSurrogate, offset+consumed)
+		}
+
+		out = utf8.AppendRune(out, r)
+		consumed += n
+	}
+
+	return
+}
+
+// encodeUTF encodes the UTF-8 in src to the given encoding and appends the result to dst.
+// If final is false, an incomplete sequence at the end of src is left for the next call.
+// offset is the position of src in the whole input and is only used for error messages.
+func encodeUTF(dst, src []byte, enc Encoding, final bool, offset int) (out []byte, consumed int, err error) {
+	out = dst
+
+	for consumed < len(src) {
+		rest := src[consumed:]
+		if !utf8.FullRune(rest) {
+			if !final {
+				return
+			}
+			return out, consumed, fmt.Errorf("%w at byte %d", ErrTruncatedInput, offset+consumed)
+		}
+
+		r, n := utf8.DecodeRune(rest)
+		if r == utf8.RuneError && n <= 1 {
+			return out, consumed, fmt.Errorf("%w at byte %d", ErrInvalidUTF8, offset+consumed)
+		}
+
+		switch enc {
+		case EncodingUTF8:
+			out = append(out, rest[:n]...)
+		case EncodingUTF16LE, EncodingUTF16BE:
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				out = appendUnit(out, uint32(r1), enc)
+				out = appendUnit(out, uint32(r2), enc)
+			} else {
+				out = appendUnit(out, uint32(r), enc)
+			}
+		case EncodingUTF32LE, EncodingUTF32BE:
+			out = appendUnit(out, uint32(r), enc)
+		default:
+			return out, consumed, ErrUnknownEncoding
+		}
+		consumed += n
+	}
+
+	return
+}
+
+// utf8Reader decodes a stream in a Unicode encoding to UTF-8.
+type utf8Reader struct {
+	r      io.Reader
+	enc    Encoding
+	in     []byte
+	out    []byte
+	offset int
+	err    error
+	sniff  bool
+}
+
+// NewUTF8Reader returns a reader that converts r from the given encoding to UTF-8.
+// If enc is EncodingUnknown, the encoding is detected from the BOM, falling back to UTF-8.
+// A leading BOM is removed.
+func NewUTF8Reader(r io.Reader, enc Encoding) io.Reader {
+	return &utf8Reader{r: r, enc: enc, sniff: true}
+}
+
+// Read implements io.Reader.
+func (ur *utf8Reader) Read(p []byte) (n int, err error) {
+	for len(ur.out) == 0 && ur.err == nil {
+		ur.fill()
+	}
+
+	n = copy(p, ur.out)
+	ur.out = ur.out[n:]
+	if len(ur.out) == 0 {
+		err = ur.err
+	}
+	return
+}
+
+// fill reads the next chunk from the underlying reader and decodes as much of it as possible.
+func (ur *utf8Reader) fill() {
+	buf := make([]byte, utfChunkSize)
+	n, readErr := ur.r.Read(buf)
+	ur.in = append(ur.in, buf[:n]...)
+	final := readErr != nil
+
+	// Wait until there's enough input to detect the BOM
+	if ur.sniff {
+		if len(ur.in) < 4 && !final {
+			return
+		}
+		var bomLen int
+		ur.in, ur.enc, bomLen = skipBOM(ur.in, ur.enc)
+		ur.offset += bomLen
+		ur.sniff = false
+	}
+
+	var consumed int
+	var err error
+	ur.out, consumed, err = decodeUTF(ur.out, ur.in, ur.enc, final, ur.offset)
+	ur.in = ur.in[consumed:]
+	ur.offset += consumed
+
+	switch {
+	case err != nil:
+		ur.err = err
+	case readErr != nil:
+		ur.err = readErr
+	}
+}
+
+// utfWriter encodes a UTF-8 stream to a Unicode encoding.
+type utfWriter struct {
+	w       io.Writer
+	enc     Encoding
+	pending []byte
+	offset  int
+	bom     bool
+}
+
+// NewEncodingWriter returns a writer that converts UTF-8 to the given encoding and writes it to w,
+// optionally starting with a BOM.
+// Invalid UTF-8 makes Write return an error, after writing the text before it.
+// Close must be called to check that no incomplete UTF-8 sequence was left at the end.
+// It does not close w.
+func NewEncodingWriter(w io.Writer, enc Encoding, withBOM bool) io.WriteCloser {
+	return &utfWriter{w: w, enc: enc, bom: withBOM}
+}
+
+// Write implements io.Writer.
+func (uw *utfWriter) Write(p []byte) (n int, err error) {
+	if uw.enc == EncodingUnknown {
+		return 0, ErrUnknownEncoding
+	}
+
+	var out []byte
+	if uw.bom {
+		out = BOM(uw.enc)
+		uw.bom = false
+	}
+
+	pending := len(uw.pending)
+	src := append(uw.pending, p...)
+	out, consumed, encErr := encodeUTF(out, src, uw.enc, false, uw.offset)
+	if encErr != nil {
+		// The valid text before the error is still written, and n is the number of bytes of p in it.
+		// The bytes left over from earlier writes are dropped, so the rest of p can be written after the invalid bytes.
+		n = consumed - pending
+		if n < 0 {
+			n = 0
+		}
+		uw.pending = nil
+		uw.offset += pending + n
+	} else {
+		n = len(p)
+		uw.pending = append([]byte(nil), src[consumed:]...)
+		uw.offset += consumed
+	}
+
+	if _, err = uw.w.Write(out); err != nil {
+		return 0, err
+	}
+	return n, encErr
+}
+
+// Close implements io.Closer.
+func (uw *utfWriter) Close() error {
+	if len(uw.pending) > 0 {
+		return fmt.Errorf("%w at byte %d", ErrTruncatedInput, uw.offset)
+	}
+	if uw.bom {
+		_, err := uw.w.Write(BOM(uw.enc))
+		return err
+	}
+	return nil
+}
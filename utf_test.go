@@ -0,0 +1,197 @@
+package texttools
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type encodingSample struct {
+	enc Encoding
+	in  []byte
+	out string
+}
+
+var encodingSamples = []encodingSample{
+	{EncodingUTF16LE, []byte{'a', 0, 0xE6, 0, 0xAC, 0x20, 0x3D, 0xD8, 0x00, 0xDE}, "aæ€😀"},
+	{EncodingUTF16BE, []byte{0, 'a', 0, 0xE6, 0x20, 0xAC, 0xD8, 0x3D, 0xDE, 0x00}, "aæ€😀"},
+	{EncodingUTF32LE, []byte{'a', 0, 0, 0, 0xE6, 0, 0, 0, 0xAC, 0x20, 0, 0, 0x00, 0xF6, 0x01, 0}, "aæ€😀"},
+	{EncodingUTF32BE, []byte{0, 0, 0, 'a', 0, 0, 0, 0xE6, 0, 0, 0x20, 0xAC, 0, 0x01, 0xF6, 0x00}, "aæ€😀"},
+	{EncodingUTF8, []byte("aæ€😀"), "aæ€😀"},
+}
+
+func TestDetectBOM(t *testing.T) {
+	samples := []struct {
+		in     []byte
+		enc    Encoding
+		bomLen int
+	}{
+		{[]byte{0xEF, 0xBB, 0xBF, 'a'}, EncodingUTF8, 3},
+		{[]byte{0xFF, 0xFE, 'a', 0}, EncodingUTF16LE, 2},
+		{[]byte{0xFE, 0xFF, 0, 'a'}, EncodingUTF16BE, 2},
+		{[]byte{0xFF, 0xFE, 0, 0}, EncodingUTF32LE, 4},
+		{[]byte{0, 0, 0xFE, 0xFF}, EncodingUTF32BE, 4},
+		{[]byte("abc"), EncodingUnknown, 0},
+		{[]byte{0xFF}, EncodingUnknown, 0},
+		{nil, EncodingUnknown, 0},
+	}
+
+	for _, sample := range samples {
+		if enc, bomLen := DetectBOM(sample.in); enc != sample.enc || bomLen != sample.bomLen {
+			t.Errorf("got %s, %d from %q, expected %s, %d", enc, bomLen, sample.in, sample.enc, sample.bomLen)
+		}
+	}
+
+	if out := StripBOM([]byte{0xEF, 0xBB, 0xBF, 'a'}); string(out) != "a" {
+		t.Errorf("got %q, expected %q", out, "a")
+	}
+}
+
+func TestDecodeToUTF8(t *testing.T) {
+	for _, sample := range encodingSamples {
+		if out, err := DecodeToUTF8(sample.in, sample.enc); err != nil || out != sample.out {
+			t.Errorf("got %q, %v from %q as %s, expected %q", out, err, sample.in, sample.enc, sample.out)
+		}
+
+		// With a BOM, both when given the encoding and when detecting it
+		withBOM := append(BOM(sample.enc), sample.in...)
+		for _, enc := range []Encoding{sample.enc, EncodingUnknown} {
+			if out, err := DecodeToUTF8(withBOM, enc); err != nil || out != sample.out {
+				t.Errorf("got %q, %v from %q as %s, expected %q", out, err, withBOM, enc, sample.out)
+			}
+		}
+	}
+
+	// Excel style UTF-16LE with BOM
+	if out, err := UTF16LEToUTF8([]byte{0xFF, 0xFE, 'a', 0, ';', 0, 'b', 0}); err != nil || out != "a;b" {
+		t.Errorf("got %q, %v, expected %q", out, err, "a;b")
+	}
+}
+
+func TestDecodeToUTF8Errors(t *testing.T) {
+	samples := []struct {
+		enc Encoding
+		in  []byte
+		err error
+	}{
+		{EncodingUTF16LE, []byte{0x3D, 0xD8, 'a', 0}, ErrUnpairedSurrogate},     // High surrogate followed by a char
+		{EncodingUTF16LE, []byte{'a', 0, 0x3D, 0xD8}, ErrUnpairedSurrogate},     // High surrogate at the end
+		{EncodingUTF16LE, []byte{0x00, 0xDE, 'a', 0}, ErrUnpairedSurrogate},     // Lone low surrogate
+		{EncodingUTF16BE, []byte{0xD8, 0x3D, 0xD8, 0x3D}, ErrUnpairedSurrogate}, // Two high surrogates
+		{EncodingUTF16LE, []byte{'a', 0, 'b'}, ErrTruncatedInput},
+		{EncodingUTF32LE, []byte{0x00, 0xD8, 0, 0}, ErrInvalidCodePoint},
+		{EncodingUTF32BE, []byte{0, 0x11, 0, 0}, ErrInvalidCodePoint},
+		{EncodingUTF32BE, []byte{0, 0, 0}, ErrTruncatedInput},
+		{EncodingUTF8, []byte{'a', 0xFF}, ErrInvalidUTF8},
+		{EncodingUTF8, []byte{'a', 0xE2, 0x82}, ErrTruncatedInput},
+	}
+
+	for _, sample := range samples {
+		if _, err := DecodeToUTF8(sample.in, sample.enc); !errors.Is(err, sample.err) {
+			t.Errorf("got %v from %q as %s, expected %v", err, sample.in, sample.enc, sample.err)
+		}
+	}
+}
+
+func TestEncodeFromUTF8(t *testing.T) {
+	for _, sample := range encodingSamples {
+		if out, err := EncodeFromUTF8(sample.out, sample.enc, false); err != nil || !bytes.Equal(out, sample.in) {
+			t.Errorf("got %q, %v from %q as %s, expected %q", out, err, sample.out, sample.enc, sample.in)
+		}
+		expected := append(BOM(sample.enc), sample.in...)
+		if out, err := EncodeFromUTF8(sample.out, sample.enc, true); err != nil || !bytes.Equal(out, expected) {
+			t.Errorf("got %q, %v from %q as %s, expected %q", out, err, sample.out, sample.enc, expected)
+		}
+	}
+
+	if _, err := UTF8ToUTF16LE("a\xff"); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("got %v, expected %v", err, ErrInvalidUTF8)
+	}
+	if _, err := EncodeFromUTF8("a", EncodingUnknown, false); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("got %v, expected %v", err, ErrUnknownEncoding)
+	}
+}
+
+func TestUTF8Reader(t *testing.T) {
+	for _, sample := range encodingSamples {
+		withBOM := append(BOM(sample.enc), bytes.Repeat(sample.in, 1000)...)
+		expected := strings.Repeat(sample.out, 1000)
+
+		// One byte at a time, to split both BOMs and surrogate pairs
+		r := NewUTF8Reader(iotest.OneByteReader(bytes.NewReader(withBOM)), EncodingUnknown)
+		if out, err := io.ReadAll(r); err != nil || string(out) != expected {
+			t.Errorf("got %q, %v as %s, expected %q", out, err, sample.enc, expected)
+		}
+
+		r = NewUTF8Reader(bytes.NewReader(withBOM), sample.enc)
+		if out, err := io.ReadAll(r); err != nil || string(out) != expected {
+			t.Errorf("got %q, %v as %s, expected %q", out, err, sample.enc, expected)
+		}
+	}
+
+	r := NewUTF8Reader(bytes.NewReader([]byte{'a', 0, 0x3D, 0xD8}), EncodingUTF16LE)
+	if out, err := io.ReadAll(r); !errors.Is(err, ErrUnpairedSurrogate) || string(out) != "a" {
+		t.Errorf("got %q, %v, expected %q, %v", out, err, "a", ErrUnpairedSurrogate)
+	}
+}
+
+func TestEncodingWriter(t *testing.T) {
+	for _, sample := range encodingSamples {
+		var buf bytes.Buffer
+		w := NewEncodingWriter(&buf, sample.enc, true)
+
+		// One byte at a time, to split multi-byte UTF-8 sequences
+		for _, c := range []byte(sample.out) {
+			if _, err := w.Write([]byte{c}); err != nil {
+				t.Fatalf("got %v as %s", err, sample.enc)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Errorf("got %v as %s", err, sample.enc)
+		}
+
+		if expected := append(BOM(sample.enc), sample.in...); !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("got %q as %s, expected %q", buf.Bytes(), sample.enc, expected)
+		}
+	}
+
+	w := NewEncodingWriter(io.Discard, EncodingUTF16LE, false)
+	if _, err := w.Write([]byte{'a', 0xE2}); err != nil {
+		t.Errorf("got %v, expected nil", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrTruncatedInput) {
+		t.Errorf("got %v, expected %v", err, ErrTruncatedInput)
+	}
+
+	// The valid text before invalid UTF-8 is written, and the rest can be written after the invalid bytes
+	var buf bytes.Buffer
+	w = NewEncodingWriter(&buf, EncodingUTF16LE, true)
+	p := []byte("cd\xffef")
+	if _, err := w.Write([]byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+	n, err := w.Write(p)
+	if n != 2 || !errors.Is(err, ErrInvalidUTF8) || !strings.Contains(err.Error(), "at byte 4") {
+		t.Errorf("got %d and %v, expected 2 and %v at byte 4", n, err, ErrInvalidUTF8)
+	}
+	if _, err := w.Write(p[n+1:]); err != nil {
+		t.Errorf("got %v after the invalid byte, expected nil", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("got %v, expected nil", err)
+	}
+	expected, _ := UTF8ToUTF16LE("abcdef")
+	if expected = append(BOM(EncodingUTF16LE), expected...); !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("got %q, expected %q", buf.Bytes(), expected)
+	}
+}
+
+func BenchmarkUTF16LEToUTF8(b *testing.B) {
+	in, _ := UTF8ToUTF16LE(strings.Repeat("some sample text with æøå and 😀", 10))
+	for i := 0; i < b.N; i++ {
+		_, _ = UTF16LEToUTF8(in)
+	}
+}
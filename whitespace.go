@@ -0,0 +1,153 @@
+package texttools
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LineEnding is the chars that end a line.
+type LineEnding string
+
+// The line endings.
+const (
+	LineEndingLF   LineEnding = "\n"   // Unix, and modern macOS
+	LineEndingCRLF LineEnding = "\r\n" // Windows
+	LineEndingCR   LineEnding = "\r"   // Classic Mac OS
+)
+
+// Any line break, including the Unicode next line, line separator and paragraph separator.
+var reLineBreak = regexp.MustCompile("\r\n|[\n\r\u0085\u2028\u2029]")
+
+// CollapseWhitespace replaces all runs of whitespace, including newlines and Unicode spaces like NBSP,
+// thin space, ideographic space and the line and paragraph separators, with a single space, and trims the text.
+func CollapseWhitespace(str string) string {
+	return strings.Join(strings.Fields(str), " ")
+}
+
+// NormalizeWhitespace collapses all whitespace within lines to single spaces and trims each line.
+// There are never more than 1 empty line in a row, line endings become \n, and the text is trimmed.
+func NormalizeWhitespace(str string) string {
+	var lines []string
+	blanks := 0
+	for _, line := range reLineBreak.Split(str, -1) {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blanks++
+			if blanks > 1 {
+				continue
+			}
+		} else {
+			blanks = 0
+		}
+		lines = append(lines, line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// NormalizeLineEndings replaces all line breaks, \r\n, \r, \n and the Unicode line breaks, with the given line ending.
+func NormalizeLineEndings(str string, ending LineEnding) string {
+	return reLineBreak.ReplaceAllLiteralString(str, string(ending))
+}
+
+// TrimLines removes the whitespace at the start and end of each line.
+// Lines end with \n or \r\n, which are kept.
+func TrimLines(str string) string {
+	return mapLines(str, func(line string) string {
+		return strings.TrimFunc(line, unicode.IsSpace)
+	})
+}
+
+// TrimTrailingWhitespace removes the whitespace at the end of each line.
+// Lines end with \n or \r\n, which are kept.
+func TrimTrailingWhitespace(str string) string {
+	return mapLines(str, func(line string) string {
+		return strings.TrimRightFunc(line, unicode.IsSpace)
+	})
+}
+
+// CollapseBlankLines removes blank lines, which are empty or only contain whitespace,
+// so there are at most max blank lines in a row.
+// Lines end with \n or \r\n.
+func CollapseBlankLines(str string, max int) string {
+	var b strings.Builder
+	b.Grow(len(str))
+
+	blanks := 0
+	for _, line := range strings.SplitAfter(str, "\n") {
+		if strings.TrimFunc(line, unicode.IsSpace) == "" && strings.HasSuffix(line, "\n") {
+			blanks++
+			if blanks > max {
+				continue
+			}
+		} else {
+			blanks = 0
+		}
+		b.WriteString(line)
+	}
+
+	return b.String()
+}
+
+// ExpandTabs replaces tabs with spaces, up to the next tab stop.
+// Tab stops are every tabSize chars, and columns are counted in chars.
+func ExpandTabs(str string, tabSize int) string {
+	if tabSize < 1 {
+		tabSize = 1
+	}
+
+	var b strings.Builder
+	b.Grow(len(str))
+
+	col := 0
+	for _, r := range str {
+		switch r {
+		case '\t':
+			spaces := tabSize - col%tabSize
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+		case '\n', '\r':
+			b.WriteRune(r)
+			col = 0
+		default:
+			b.WriteRune(r)
+			col++
+		}
+	}
+
+	return b.String()
+}
+
+// UnexpandTabs replaces the spaces and tabs used for indentation at the start of each line with as many tabs as possible,
+// followed by the spaces needed to reach the same column.
+// Tab stops are every tabSize chars.
+func UnexpandTabs(str string, tabSize int) string {
+	if tabSize < 1 {
+		tabSize = 1
+	}
+
+	return mapLines(str, func(line string) string {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			return line
+		}
+
+		width := utf8.RuneCountInString(ExpandTabs(line[:indent], tabSize))
+		return strings.Repeat("\t", width/tabSize) + strings.Repeat(" ", width%tabSize) + line[indent:]
+	})
+}
+
+// mapLines replaces each line with the result of fn, keeping the line endings, \n or \r\n.
+func mapLines(str string, fn func(line string) string) string {
+	var b strings.Builder
+	b.Grow(len(str))
+
+	for _, line := range strings.SplitAfter(str, "\n") {
+		content := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		b.WriteString(fn(content))
+		b.WriteString(line[len(content):])
+	}
+
+	return b.String()
+}
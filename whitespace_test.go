@@ -0,0 +1,129 @@
+package texttools
+
+import "testing"
+
+func TestCollapseWhitespace(t *testing.T) {
+	samples := []sample{
+		{"  one \t two\r\n\r\nthree  ", "one two three"},
+		{"a\u00a0b\u2009c\u3000d\u2028e\u2029f\u0085g", "a b c d e f g"},
+		{" \r\n \t ", ""},
+		{"", ""},
+	}
+
+	for _, sample := range samples {
+		if out := CollapseWhitespace(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	samples := []sample{
+		{"  one \t two  three  \n \n\n\n  four  \n", "one two three\n\nfour"},
+		{"one\r\ntwo\rthree\u2028four", "one\ntwo\nthree\nfour"},
+		{"\n\n  one\u00a0 two \r\n\r\n\r\n", "one two"},
+	}
+
+	for _, sample := range samples {
+		if out := NormalizeWhitespace(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	in := "one\r\ntwo\rthree\nfour\u0085five\u2028six\u2029seven\n\r"
+	samples := []struct {
+		ending LineEnding
+		out    string
+	}{
+		{LineEndingLF, "one\ntwo\nthree\nfour\nfive\nsix\nseven\n\n"},
+		{LineEndingCRLF, "one\r\ntwo\r\nthree\r\nfour\r\nfive\r\nsix\r\nseven\r\n\r\n"},
+		{LineEndingCR, "one\rtwo\rthree\rfour\rfive\rsix\rseven\r\r"},
+	}
+
+	for _, sample := range samples {
+		if out := NormalizeLineEndings(in, sample.ending); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, in, sample.out)
+		}
+	}
+}
+
+func TestTrimLines(t *testing.T) {
+	samples := []sample{
+		{"  one  \r\n\ttwo\t\n three\u00a0", "one\r\ntwo\nthree"},
+		{"\n  \n", "\n\n"},
+	}
+
+	for _, sample := range samples {
+		if out := TrimLines(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestTrimTrailingWhitespace(t *testing.T) {
+	samples := []sample{
+		{"  one  \r\n\ttwo\t\n three\u00a0", "  one\r\n\ttwo\n three"},
+		{"code; \n\n", "code;\n\n"},
+	}
+
+	for _, sample := range samples {
+		if out := TrimTrailingWhitespace(sample.in); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestCollapseBlankLines(t *testing.T) {
+	in := "one\n\n \n\t\ntwo\r\n\r\n\r\nthree\n"
+	samples := []struct {
+		max int
+		out string
+	}{
+		{0, "one\ntwo\r\nthree\n"},
+		{1, "one\n\ntwo\r\n\r\nthree\n"},
+		{2, "one\n\n \ntwo\r\n\r\n\r\nthree\n"},
+	}
+
+	for _, sample := range samples {
+		if out := CollapseBlankLines(in, sample.max); out != sample.out {
+			t.Errorf("got %q from %q with max %d, expected %q", out, in, sample.max, sample.out)
+		}
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	samples := []struct {
+		tabSize int
+		in, out string
+	}{
+		{4, "\tone\ttwo", "    one two"},
+		{4, "ab\tc\r\nabcd\te", "ab  c\r\nabcd    e"},
+		{8, "æøå\tx", "æøå     x"},
+		{0, "a\tb", "a b"},
+	}
+
+	for _, sample := range samples {
+		if out := ExpandTabs(sample.in, sample.tabSize); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}
+
+func TestUnexpandTabs(t *testing.T) {
+	samples := []struct {
+		tabSize int
+		in, out string
+	}{
+		{4, "        one  two\n      three\r\n", "\t\tone  two\n\t  three\r\n"},
+		{4, "  \t one", "\t one"},
+		{2, "no indent\n", "no indent\n"},
+	}
+
+	for _, sample := range samples {
+		if out := UnexpandTabs(sample.in, sample.tabSize); out != sample.out {
+			t.Errorf("got %q from %q, expected %q", out, sample.in, sample.out)
+		}
+	}
+}